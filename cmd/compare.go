@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/compare"
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+var compareCmd = &cobra.Command{
+	Use:   "compare <source> <destination>",
+	Short: "Compare two Vault locations",
+	Long: `Compares the secrets under two locations, given as [namespace:]mount/path, for example
+"staging:kv/app" and "prod:kv/app". Secrets are matched by their path relative to each
+location and reported as only in one side or with the keys that differ. Values are
+always masked, and no local files are read or written.
+
+Without a namespace, --src-namespace and --dst-namespace are used, which default to
+--vault-namespace; nested namespaces such as team-a/sub are given that way, as a ":" after
+a "/" is part of the mount or path. The two sides can be on different
+clusters with --src-addr/--src-token and --dst-addr/--dst-token, or the tokens can be
+given through $VAULT_SYNC_SRC_TOKEN and $VAULT_SYNC_DST_TOKEN.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		showIdentical, _ := cmd.Flags().GetBool("show-identical")
+
+		src, err := config.ParseLocation(args[0], locationFromFlags(cmd, "src", cfg.Location()))
+		if err != nil {
+			return errors.New("parse_source_location", err)
+		}
+		dst, err := config.ParseLocation(args[1], locationFromFlags(cmd, "dst", cfg.Location()))
+		if err != nil {
+			return errors.New("parse_destination_location", err)
+		}
+
+		logger.InfoCtx(ctx, "Starting compare command",
+			"source", src.String(),
+			"destination", dst.String())
+
+		srcCfg := cfg.ForLocation(src)
+		dstCfg := cfg.ForLocation(dst)
+
+		if err := srcCfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_source_config")
+		}
+		if err := dstCfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_destination_config")
+		}
+
+		srcClient, err := newClient(ctx, srcCfg)
+		if err != nil {
+			return errors.Wrap(err, "create_source_vault_client")
+		}
+
+		dstClient, err := newClient(ctx, dstCfg)
+		if err != nil {
+			return errors.Wrap(err, "create_destination_vault_client")
+		}
+
+		return compare.New(srcClient, srcCfg, dstClient, dstCfg).Compare(ctx, showIdentical)
+	},
+}
+
+func init() {
+	compareCmd.Flags().String("src-addr", "", "Vault address of the source (default: --vault-addr)")
+	compareCmd.Flags().String("src-token", "", "Vault token for the source (default: --vault-token)")
+	compareCmd.Flags().String("dst-addr", "", "Vault address of the destination (default: --vault-addr)")
+	compareCmd.Flags().String("dst-token", "", "Vault token for the destination (default: --vault-token)")
+	compareCmd.Flags().String("src-namespace", "", "Vault namespace of the source (default: --vault-namespace)")
+	compareCmd.Flags().String("dst-namespace", "", "Vault namespace of the destination (default: --vault-namespace)")
+	compareCmd.Flags().Bool("show-identical", false, "Also list secrets that are identical on both sides")
+
+	rootCmd.AddCommand(compareCmd)
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/transfer"
+)
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <source> <destination>",
+	Short: "Copy a secret or subtree within Vault",
+	Long: `Copies secrets server-side, without writing them to local files. The current version
+is copied together with its custom metadata; with --history, every readable version is
+replayed in order instead. Version numbers at the destination start over.
+
+With --recursive, every secret under the source path is copied to the same relative
+path under the destination. --dst-mount and --dst-namespace copy to another KV mount
+or namespace. KV v1 mounts have no versions, so only the current data is copied
+from or to them. Existing destination secrets fail the copy unless --on-conflict is skip
+or overwrite.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransfer(cmd, args[0], args[1], false)
+	},
+}
+
+// runTransfer is shared by the cp and mv commands.
+func runTransfer(cmd *cobra.Command, srcPath, dstPath string, move bool) error {
+	ctx := cmd.Context()
+
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	history, _ := cmd.Flags().GetBool("history")
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+	dstMount, _ := cmd.Flags().GetString("dst-mount")
+	dstNamespace, _ := cmd.Flags().GetString("dst-namespace")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	autoApprove, _ := cmd.Flags().GetBool("yes")
+
+	cfg.DryRun = dryRun
+	cfg.AutoApprove = autoApprove
+
+	logger.InfoCtx(ctx, "Starting "+cmd.Name()+" command",
+		"source", srcPath,
+		"destination", dstPath,
+		"recursive", recursive,
+		"history", history,
+		"on_conflict", onConflict,
+		"dst_mount", dstMount,
+		"dst_namespace", dstNamespace,
+		"dry_run", dryRun)
+
+	policy, err := transfer.ParseConflictPolicy(onConflict)
+	if err != nil {
+		return errors.New("parse_"+cmd.Name()+"_flags", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return errors.Wrap(err, "validate_config")
+	}
+
+	dstCfg := cfg.Clone()
+	if cmd.Flags().Changed("dst-mount") {
+		dstCfg.KVMount = dstMount
+	}
+	if cmd.Flags().Changed("dst-namespace") {
+		dstCfg.VaultNamespace = dstNamespace
+	}
+
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return errors.Wrap(err, "create_vault_client")
+	}
+
+	dstClient, err := newClient(ctx, dstCfg)
+	if err != nil {
+		return errors.Wrap(err, "create_vault_client")
+	}
+
+	return transfer.New(client, cfg, dstClient, dstCfg).Copy(ctx, srcPath, dstPath, transfer.Options{
+		Recursive:  recursive,
+		Move:       move,
+		History:    history,
+		OnConflict: policy,
+	})
+}
+
+func addTransferFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("recursive", "r", false, "Copy every secret under the source path")
+	cmd.Flags().Bool("history", false, "Replay every readable version instead of only the current one")
+	cmd.Flags().String("on-conflict", string(transfer.ConflictFail), "What to do when a destination secret exists: fail, skip or overwrite")
+	cmd.Flags().String("dst-mount", "", "KV v2 mount of the destination (default: --kv-mount)")
+	cmd.Flags().String("dst-namespace", "", "Vault namespace of the destination (default: --vault-namespace)")
+	cmd.Flags().Bool("dry-run", false, "Show what would be copied without writing to Vault")
+	cmd.Flags().Bool("yes", false, "Do not ask for confirmation")
+}
+
+func init() {
+	addTransferFlags(cpCmd)
+
+	rootCmd.AddCommand(cpCmd)
+}
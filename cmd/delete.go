@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"vault-sync/internal/deletion"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <path>",
+	Short: "Soft-delete secret versions in Vault",
+	Long: `Soft-deletes the current version of a secret, or the versions given with --versions.
+Soft-deleted versions can be restored with 'vault-sync undelete'. With --metadata, the
+secret is removed permanently together with all of its versions and metadata.
+
+With --recursive, every secret under the path is changed. The planned changes are
+listed and confirmed once before anything is written (unless --yes is used).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		action := deletion.ActionDelete
+		if metadata, _ := cmd.Flags().GetBool("metadata"); metadata {
+			action = deletion.ActionDeleteMetadata
+		}
+		return runDeletion(cmd, args[0], action)
+	},
+}
+
+// runDeletion is shared by the delete, undelete and destroy commands.
+func runDeletion(cmd *cobra.Command, secretPath string, action deletion.Action) error {
+	ctx := cmd.Context()
+
+	versions, _ := cmd.Flags().GetInt64Slice("versions")
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	autoApprove, _ := cmd.Flags().GetBool("yes")
+
+	cfg.DryRun = dryRun
+	cfg.AutoApprove = autoApprove
+
+	logger.InfoCtx(ctx, "Starting "+string(action)+" command",
+		"path", secretPath,
+		"versions", versions,
+		"recursive", recursive,
+		"dry_run", dryRun,
+		"auto_approve", autoApprove)
+
+	if recursive && len(versions) > 0 {
+		return errors.New("parse_"+string(action)+"_flags",
+			fmt.Errorf("--versions cannot be combined with --recursive, version numbers differ between secrets"))
+	}
+	if action == deletion.ActionDeleteMetadata && len(versions) > 0 {
+		return errors.New("parse_delete_flags",
+			fmt.Errorf("--metadata removes all versions and cannot be combined with --versions"))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return errors.Wrap(err, "validate_config")
+	}
+
+	client, err := newClient(ctx, cfg)
+	if err != nil {
+		return errors.Wrap(err, "create_vault_client")
+	}
+
+	return deletion.New(client, cfg).Run(ctx, secretPath, deletion.Options{
+		Action:    action,
+		Versions:  versions,
+		Recursive: recursive,
+	})
+}
+
+func addDeletionFlags(cmd *cobra.Command) {
+	cmd.Flags().Int64Slice("versions", nil, "Versions to change (default: the current version)")
+	cmd.Flags().BoolP("recursive", "r", false, "Change every secret under the path")
+	cmd.Flags().Bool("dry-run", false, "Show what would change without writing to Vault")
+	cmd.Flags().Bool("yes", false, "Do not ask for confirmation")
+}
+
+func init() {
+	addDeletionFlags(deleteCmd)
+	deleteCmd.Flags().Bool("metadata", false, "Permanently delete the metadata and all versions")
+
+	rootCmd.AddCommand(deleteCmd)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/deletion"
+)
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy <path>",
+	Short: "Permanently destroy secret versions in Vault",
+	Long: `Permanently removes the data of the current version of a secret, or of the versions
+given with --versions. Destroyed versions stay in the version history but cannot be
+read or restored.
+
+With --recursive, every secret under the path is changed. The planned changes are
+listed and confirmed once before anything is written (unless --yes is used).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDeletion(cmd, args[0], deletion.ActionDestroy)
+	},
+}
+
+func init() {
+	addDeletionFlags(destroyCmd)
+
+	rootCmd.AddCommand(destroyCmd)
+}
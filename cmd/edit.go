@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/edit"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <path>",
+	Short: "Edit a secret in $EDITOR",
+	Long: `Reads the latest version of a secret and opens it as YAML in $VISUAL or $EDITOR
+(vi if neither is set). The temp file is created with 0600 permissions and removed
+afterwards. When the editor exits, the changes are shown and, once approved, written
+as a new version with check-and-set on the version that was opened, so a change made
+by someone else meanwhile is not overwritten; the edit can then be redone on top of
+the latest version. Leaving the file empty aborts the edit.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		autoApprove, _ := cmd.Flags().GetBool("yes")
+		cfg.AutoApprove = autoApprove
+
+		logger.InfoCtx(ctx, "Starting edit command", "path", args[0], "auto_approve", autoApprove)
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return edit.New(client, cfg).Edit(ctx, args[0])
+	},
+}
+
+func init() {
+	editCmd.Flags().Bool("yes", false, "Write the changes without asking for approval")
+
+	rootCmd.AddCommand(editCmd)
+}
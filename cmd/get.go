@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/get"
+	"vault-sync/internal/logger"
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <path> [key]",
+	Short: "Print a secret or a single value",
+	Long: `Reads a secret from Vault and prints it to stdout, as YAML like the files written by
+pull, or as JSON with --format json. With a key, only that value is printed, raw and
+exactly as stored (no newline is added), or as a YAML or JSON string with --format.
+With --version, that version of a KV v2 secret is read instead of the latest.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		format, _ := cmd.Flags().GetString("format")
+		version, _ := cmd.Flags().GetInt64("version")
+
+		var key string
+		if len(args) == 2 {
+			key = args[1]
+		}
+
+		logger.InfoCtx(ctx, "Starting get command",
+			"path", args[0],
+			"key", key,
+			"format", format,
+			"version", version)
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return get.New(client, cfg).Get(ctx, args[0], key, version, format)
+	},
+}
+
+func init() {
+	getCmd.Flags().String("format", "", "Output format: yaml, json or raw (default yaml, or raw for a single key)")
+	getCmd.Flags().Int64("version", 0, "Read this version instead of the latest")
+
+	rootCmd.AddCommand(getCmd)
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/history"
+	"vault-sync/internal/logger"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <path>",
+	Short: "Show the version history of a secret",
+	Long: `Lists every KV v2 version of a secret with its created, deleted and destroyed
+state, read from the metadata endpoint. With --diff, the key-level changes between
+consecutive versions are shown as well.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		showDiff, _ := cmd.Flags().GetBool("diff")
+
+		logger.InfoCtx(ctx, "Starting history command", "path", args[0], "diff", showDiff)
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return history.New(client, cfg).Show(ctx, args[0], showDiff)
+	},
+}
+
+func init() {
+	historyCmd.Flags().Bool("diff", false, "Show key-level changes between consecutive versions")
+
+	rootCmd.AddCommand(historyCmd)
+}
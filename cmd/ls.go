@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/browse"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+var lsCmd = &cobra.Command{
+	Use:   "ls [path]",
+	Short: "List the secrets and directories below a path",
+	Long: `Lists the secrets and directories directly below a path of the KV mount (--base-path
+or the mount root if no path is given). Directories end in a slash. With --long, the
+key count, current version and update time of every secret are shown as well; this
+reads each secret and its metadata. --match filters entries with a glob, and
+--format json prints the entries as JSON.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		basePath := cfg.BasePath
+		if len(args) == 1 {
+			basePath = args[0]
+		}
+		opts := browseOptions(cmd)
+
+		logger.InfoCtx(ctx, "Starting ls command",
+			"path", basePath,
+			"match", opts.Match,
+			"long", opts.Long,
+			"format", opts.Format)
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return browse.New(client, cfg).List(ctx, basePath, opts)
+	},
+}
+
+// browseOptions reads the flags shared by ls and tree.
+func browseOptions(cmd *cobra.Command) browse.Options {
+	var opts browse.Options
+	opts.Match, _ = cmd.Flags().GetString("match")
+	opts.Long, _ = cmd.Flags().GetBool("long")
+	opts.Format, _ = cmd.Flags().GetString("format")
+	if cmd.Flags().Lookup("depth") != nil {
+		opts.Depth, _ = cmd.Flags().GetInt("depth")
+	}
+	return opts
+}
+
+func addBrowseFlags(cmd *cobra.Command) {
+	cmd.Flags().String("match", "", "Only show entries whose name, or relative path if the glob has a slash, matches this glob")
+	cmd.Flags().BoolP("long", "l", false, "Show key count, version and update time of every secret")
+	cmd.Flags().String("format", browse.FormatText, "Output format: text or json")
+}
+
+func init() {
+	addBrowseFlags(lsCmd)
+
+	rootCmd.AddCommand(lsCmd)
+}
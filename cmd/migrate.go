@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/migrate"
+	"vault-sync/internal/transfer"
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate secrets between namespaces, mounts or clusters",
+	Long: `Walks every secret under the source path and writes it to the same relative path under
+the destination, which can be another namespace, mount or Vault cluster. Source settings
+default to the global flags and destination settings default to the source.
+
+Each change is shown as a diff and approved like a push (unless --yes is used). Secrets
+that already exist at the destination with different data are handled by --on-conflict:
+fail before writing anything, skip them, or overwrite them. Custom metadata is copied
+along. Finally, every migrated secret is read back and compared with the source.
+
+Tokens can also be given through $VAULT_SYNC_SRC_TOKEN and $VAULT_SYNC_DST_TOKEN.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		autoApprove, _ := cmd.Flags().GetBool("yes")
+
+		cfg.DryRun = dryRun
+		cfg.AutoApprove = autoApprove
+
+		src := locationFromFlags(cmd, "src", cfg.Location())
+		dst := locationFromFlags(cmd, "dst", src)
+
+		logger.InfoCtx(ctx, "Starting migrate command",
+			"source", src.String(),
+			"destination", dst.String(),
+			"on_conflict", onConflict,
+			"dry_run", dryRun,
+			"auto_approve", autoApprove)
+
+		policy, err := transfer.ParseConflictPolicy(onConflict)
+		if err != nil {
+			return errors.New("parse_migrate_flags", err)
+		}
+
+		srcCfg := cfg.ForLocation(src)
+		dstCfg := cfg.ForLocation(dst)
+
+		if err := srcCfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_source_config")
+		}
+		if err := dstCfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_destination_config")
+		}
+
+		srcClient, err := newClient(ctx, srcCfg)
+		if err != nil {
+			return errors.Wrap(err, "create_source_vault_client")
+		}
+
+		dstClient, err := newClient(ctx, dstCfg)
+		if err != nil {
+			return errors.Wrap(err, "create_destination_vault_client")
+		}
+
+		return migrate.New(srcClient, srcCfg, dstClient, dstCfg).Migrate(ctx, policy)
+	},
+}
+
+// locationFromFlags overrides defaults with the --<prefix>-addr, -token,
+// -namespace, -mount and -path flags that were set. The token falls back to
+// $VAULT_SYNC_<PREFIX>_TOKEN.
+func locationFromFlags(cmd *cobra.Command, prefix string, defaults config.Location) config.Location {
+	loc := defaults
+
+	if token := os.Getenv("VAULT_SYNC_" + strings.ToUpper(prefix) + "_TOKEN"); token != "" {
+		loc.Token = token
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed(prefix + "-addr") {
+		loc.Addr, _ = flags.GetString(prefix + "-addr")
+	}
+	if flags.Changed(prefix + "-token") {
+		loc.Token, _ = flags.GetString(prefix + "-token")
+	}
+	if flags.Changed(prefix + "-namespace") {
+		loc.Namespace, _ = flags.GetString(prefix + "-namespace")
+	}
+	if flags.Changed(prefix + "-mount") {
+		loc.Mount, _ = flags.GetString(prefix + "-mount")
+	}
+	if flags.Changed(prefix + "-path") {
+		loc.Path, _ = flags.GetString(prefix + "-path")
+	}
+	return loc
+}
+
+func addLocationFlags(cmd *cobra.Command, prefix, description, defaults string) {
+	cmd.Flags().String(prefix+"-addr", "", "Vault address of the "+description+" (default: "+defaults+")")
+	cmd.Flags().String(prefix+"-token", "", "Vault token for the "+description+" (default: "+defaults+")")
+	cmd.Flags().String(prefix+"-namespace", "", "Vault namespace of the "+description+" (default: "+defaults+")")
+	cmd.Flags().String(prefix+"-mount", "", "KV v2 mount of the "+description+" (default: "+defaults+")")
+	cmd.Flags().String(prefix+"-path", "", "Base path of the "+description+" (default: "+defaults+")")
+}
+
+func init() {
+	addLocationFlags(migrateCmd, "src", "source", "global flags")
+	addLocationFlags(migrateCmd, "dst", "destination", "source")
+	migrateCmd.Flags().String("on-conflict", string(transfer.ConflictFail), "What to do when a destination secret exists with different data: fail, skip or overwrite")
+	migrateCmd.Flags().Bool("dry-run", false, "Show diffs without writing to the destination")
+	migrateCmd.Flags().Bool("yes", false, "Auto-approve all changes without prompting")
+
+	rootCmd.AddCommand(migrateCmd)
+}
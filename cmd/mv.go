@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:   "mv <source> <destination>",
+	Short: "Move or rename a secret or subtree within Vault",
+	Long: `Copies secrets server-side like 'vault-sync cp' and then permanently deletes each
+source secret with its metadata and all versions, once it has been copied. Use
+--history to keep the version history at the destination.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransfer(cmd, args[0], args[1], true)
+	},
+}
+
+func init() {
+	addTransferFlags(mvCmd)
+
+	rootCmd.AddCommand(mvCmd)
+}
@@ -1,39 +1,101 @@
 package cmd
 
 import (
-	"context"
+	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
+	"vault-sync/internal/config"
 	"vault-sync/internal/errors"
 	"vault-sync/internal/logger"
 	"vault-sync/internal/pull"
-	"vault-sync/internal/vault"
 )
 
 var pullCmd = &cobra.Command{
 	Use:   "pull",
 	Short: "Pull secrets from Vault to local filesystem",
 	Long: `Recursively downloads secrets from Vault KV v2 and writes them as YAML files
-to the local filesystem. The directory structure mirrors the Vault path structure.`,
+to the local filesystem. The directory structure mirrors the Vault path structure.
+
+With --as-of, each secret is pulled at the version that was current at that time,
+to reconstruct the state of an environment. With --version, a single version of
+the secret named by --base-path is written into the output directory.
+
+With --kv-mount '*' every KV mount in the namespace is pulled, each into a directory
+named after the mount; a comma-separated list selects specific mounts. With
+--recurse-namespaces, every namespace below --vault-namespace is pulled into the
+directory of its relative path, such as team-a/ and team-a/sub/.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-		logger.InfoCtx(ctx, "Starting pull command")
+		ctx := cmd.Context()
+
+		resume, _ := cmd.Flags().GetBool("resume")
+		version, _ := cmd.Flags().GetInt64("version")
+		asOf, _ := cmd.Flags().GetString("as-of")
+		recurseNamespaces, _ := cmd.Flags().GetBool("recurse-namespaces")
+		cfg.Resume = resume
+		cfg.RecurseNamespaces = recurseNamespaces
+
+		logger.InfoCtx(ctx, "Starting pull command",
+			"resume", resume,
+			"version", version,
+			"as_of", asOf,
+			"recurse_namespaces", recurseNamespaces)
+
+		if err := applyPullSelection(version, asOf, resume); err != nil {
+			return errors.New("parse_pull_flags", err)
+		}
 		
 		if err := cfg.Validate(); err != nil {
 			return errors.Wrap(err, "validate_config")
 		}
 
-		client, err := vault.NewClient(cfg)
-		if err != nil {
-			return errors.Wrap(err, "create_vault_client")
-		}
+		return forEachTarget(ctx, false, func(c *config.Config) error {
+			client, err := newClient(ctx, c)
+			if err != nil {
+				return errors.Wrap(err, "create_vault_client")
+			}
 
-		puller := pull.New(client, cfg)
-		
-		return puller.Pull(ctx)
+			fileCodec, err := newCodec(c, client)
+			if err != nil {
+				return errors.Wrap(err, "create_codec")
+			}
+
+			return pull.New(client, c, fileCodec).Pull(ctx)
+		})
 	},
 }
 
+func applyPullSelection(version int64, asOf string, resume bool) error {
+	if version > 0 {
+		if asOf != "" {
+			return fmt.Errorf("--version and --as-of cannot be combined")
+		}
+		if resume {
+			return fmt.Errorf("--resume cannot be used with --version")
+		}
+		if cfg.BasePath == "" {
+			return fmt.Errorf("--version requires --base-path to name a single secret")
+		}
+		if cfg.MultiMount() || cfg.RecurseNamespaces {
+			return fmt.Errorf("--version requires a single --kv-mount and cannot be used with --recurse-namespaces")
+		}
+		cfg.SecretVersion = version
+	}
+	if asOf != "" {
+		t, err := time.Parse(time.RFC3339, asOf)
+		if err != nil {
+			return fmt.Errorf("invalid --as-of %q, expected RFC 3339 such as 2024-01-02T15:04:05Z: %w", asOf, err)
+		}
+		cfg.AsOf = t
+	}
+	return nil
+}
+
 func init() {
+	pullCmd.Flags().Bool("resume", false, "Resume an interrupted pull, skipping secrets already pulled")
+	pullCmd.Flags().Int64("version", 0, "Pull this version of the secret at --base-path")
+	pullCmd.Flags().String("as-of", "", "Pull each secret as it was at this RFC 3339 timestamp")
+	pullCmd.Flags().Bool("recurse-namespaces", false, "Pull every namespace below --vault-namespace into its own directory")
+
 	rootCmd.AddCommand(pullCmd)
 }
\ No newline at end of file
@@ -1,13 +1,14 @@
 package cmd
 
 import (
-	"context"
+	"fmt"
 
 	"github.com/spf13/cobra"
+	"vault-sync/internal/config"
 	"vault-sync/internal/errors"
 	"vault-sync/internal/logger"
 	"vault-sync/internal/push"
-	"vault-sync/internal/vault"
+	"vault-sync/internal/tui"
 )
 
 var pushCmd = &cobra.Command{
@@ -15,39 +16,97 @@ var pushCmd = &cobra.Command{
 	Short: "Push local YAML files to Vault",
 	Long: `Reads local YAML files and pushes changes back to Vault KV v2.
 For each secret, it fetches the current value from Vault, produces a human-readable
-diff, and prompts the user for approval before writing (unless --yes is used).`,
+diff, and prompts the user for approval before writing (unless --yes is used).
+
+With --kv-mount '*' or a comma-separated list of mounts, each top-level directory of
+the output directory is pushed to the mount of the same name. With --recurse-namespaces,
+each namespace directory written by pull --recurse-namespaces is pushed to its namespace.
+
+With --patch, existing KV v2 secrets are updated with a PATCH that only sends the
+added, changed and removed keys, so keys changed concurrently by others are kept.
+Where the server or policy does not allow PATCH, the full secret is written instead.
+
+With --interactive, each changed key is shown on its own and can be accepted, rejected
+or edited; only the accepted keys are written. "A" accepts the remaining keys of the
+secret and "q" stops the push, which can be continued with --resume.
+
+With --tui, every secret is compared with Vault first and the changes are reviewed in a
+full-screen view: select the secrets to apply, inspect their changes with values hidden
+until shown, and confirm once at the end.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 		
 		// Get flag values
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		autoApprove, _ := cmd.Flags().GetBool("yes")
+		resume, _ := cmd.Flags().GetBool("resume")
+		recurseNamespaces, _ := cmd.Flags().GetBool("recurse-namespaces")
+		patch, _ := cmd.Flags().GetBool("patch")
+		interactive, _ := cmd.Flags().GetBool("interactive")
+		useTUI, _ := cmd.Flags().GetBool("tui")
 		
 		cfg.DryRun = dryRun
 		cfg.AutoApprove = autoApprove
+		cfg.Resume = resume
+		cfg.RecurseNamespaces = recurseNamespaces
+		cfg.Patch = patch
+		cfg.Interactive = interactive
+		cfg.TUI = useTUI
 		
 		logger.InfoCtx(ctx, "Starting push command", 
 			"dry_run", dryRun, 
-			"auto_approve", autoApprove)
+			"auto_approve", autoApprove,
+			"resume", resume,
+			"recurse_namespaces", recurseNamespaces,
+			"patch", patch,
+			"interactive", interactive,
+			"tui", useTUI)
+
+		if interactive && (autoApprove || dryRun) {
+			return fmt.Errorf("--interactive cannot be combined with --yes or --dry-run")
+		}
+		if useTUI {
+			if autoApprove || dryRun || interactive {
+				return fmt.Errorf("--tui cannot be combined with --yes, --dry-run or --interactive")
+			}
+			if !tui.IsTerminal() {
+				return fmt.Errorf("--tui needs an interactive terminal")
+			}
+		}
 
 		if err := cfg.Validate(); err != nil {
 			return errors.Wrap(err, "validate_config")
 		}
 
-		client, err := vault.NewClient(cfg)
-		if err != nil {
-			return errors.Wrap(err, "create_vault_client")
-		}
+		err := forEachTarget(ctx, true, func(c *config.Config) error {
+			client, err := newClient(ctx, c)
+			if err != nil {
+				return errors.Wrap(err, "create_vault_client")
+			}
 
-		pusher := push.New(client, cfg)
-		
-		return pusher.Push(ctx)
+			fileCodec, err := newCodec(c, client)
+			if err != nil {
+				return errors.Wrap(err, "create_codec")
+			}
+
+			return push.New(client, c, fileCodec).Push(ctx)
+		})
+		if push.IsStopped(err) {
+			// Quitting a review is not a failure
+			return nil
+		}
+		return err
 	},
 }
 
 func init() {
 	pushCmd.Flags().Bool("dry-run", false, "Show diffs without writing to Vault")
 	pushCmd.Flags().Bool("yes", false, "Auto-approve all changes without prompting")
+	pushCmd.Flags().Bool("resume", false, "Resume an interrupted push, skipping secrets already processed")
+	pushCmd.Flags().Bool("patch", false, "Send only the changed keys of existing secrets with a KV v2 PATCH")
+	pushCmd.Flags().BoolP("interactive", "i", false, "Review each changed key, accepting, rejecting or editing it")
+	pushCmd.Flags().Bool("tui", false, "Review all changes in a full-screen terminal UI before applying them")
+	pushCmd.Flags().Bool("recurse-namespaces", false, "Push the directory of every namespace below --vault-namespace to that namespace")
 	
 	rootCmd.AddCommand(pushCmd)
 }
\ No newline at end of file
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/put"
+)
+
+var putCmd = &cobra.Command{
+	Use:   "put <path> key=value [key=@file] [key=-]...",
+	Short: "Set keys of a secret",
+	Long: `Merges the given keys into the latest version of a secret, creating the secret if it
+does not exist; keys that are not given keep their value. A value of @file is read
+from that file and a value of - from stdin, byte for byte; write @@ for a literal value
+starting with @. The key changes are shown with values hidden and written once
+approved (unless --yes is used), with check-and-set on the version they were made
+from. Reading a value from stdin requires --yes, as stdin cannot answer the prompt.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		autoApprove, _ := cmd.Flags().GetBool("yes")
+
+		cfg.DryRun = dryRun
+		cfg.AutoApprove = autoApprove
+
+		logger.InfoCtx(ctx, "Starting put command",
+			"path", args[0],
+			"keys", len(args)-1,
+			"dry_run", dryRun,
+			"auto_approve", autoApprove)
+
+		if put.ReadsStdin(args[1:]) && !autoApprove && !dryRun {
+			return fmt.Errorf("key=- reads the value from stdin, which then cannot answer the prompt; add --yes")
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		assignments, err := put.ParseAssignments(args[1:], os.Stdin)
+		if err != nil {
+			return errors.NewWithPath("parse_assignments", args[0], err)
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return put.New(client, cfg).Put(ctx, args[0], assignments)
+	},
+}
+
+func init() {
+	putCmd.Flags().Bool("dry-run", false, "Show the changes without writing to Vault")
+	putCmd.Flags().Bool("yes", false, "Write the changes without asking for approval")
+
+	rootCmd.AddCommand(putCmd)
+}
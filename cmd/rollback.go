@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/rollback"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <path>",
+	Short: "Roll back a secret or subtree to a previous version",
+	Long: `Restores a secret to an earlier KV v2 version by writing the historical data as a
+new version, so the rollback itself shows up in the history and can be undone.
+
+With --to-version, the given version of the secret is restored. With --to-time, the
+version that was current at that time is restored instead; combined with --recursive,
+every secret under the path is rolled back to the same point in time.
+
+The diff against the current data is shown and approval is asked for each secret
+(unless --yes is used). Writes are checked against the version the diff was made
+from, so concurrent changes are not overwritten.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		toVersion, _ := cmd.Flags().GetInt64("to-version")
+		toTime, _ := cmd.Flags().GetString("to-time")
+		recursive, _ := cmd.Flags().GetBool("recursive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		autoApprove, _ := cmd.Flags().GetBool("yes")
+
+		cfg.DryRun = dryRun
+		cfg.AutoApprove = autoApprove
+
+		logger.InfoCtx(ctx, "Starting rollback command",
+			"path", args[0],
+			"to_version", toVersion,
+			"to_time", toTime,
+			"recursive", recursive,
+			"dry_run", dryRun,
+			"auto_approve", autoApprove)
+
+		target, err := rollbackTarget(toVersion, toTime, recursive)
+		if err != nil {
+			return errors.New("parse_rollback_target", err)
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return rollback.New(client, cfg).Rollback(ctx, args[0], target, recursive)
+	},
+}
+
+func rollbackTarget(toVersion int64, toTime string, recursive bool) (rollback.Target, error) {
+	switch {
+	case toVersion > 0 && toTime != "":
+		return rollback.Target{}, fmt.Errorf("--to-version and --to-time cannot be combined")
+	case toVersion > 0:
+		if recursive {
+			return rollback.Target{}, fmt.Errorf("--recursive requires --to-time, version numbers differ between secrets")
+		}
+		return rollback.Target{Version: toVersion}, nil
+	case toTime != "":
+		t, err := time.Parse(time.RFC3339, toTime)
+		if err != nil {
+			return rollback.Target{}, fmt.Errorf("invalid --to-time %q, expected RFC 3339 such as 2024-01-02T15:04:05Z: %w", toTime, err)
+		}
+		return rollback.Target{Time: t}, nil
+	default:
+		return rollback.Target{}, fmt.Errorf("one of --to-version or --to-time is required")
+	}
+}
+
+func init() {
+	rollbackCmd.Flags().Int64("to-version", 0, "Version of the secret to restore")
+	rollbackCmd.Flags().String("to-time", "", "Restore the version that was current at this RFC 3339 timestamp")
+	rollbackCmd.Flags().BoolP("recursive", "r", false, "Roll back every secret under the path (requires --to-time)")
+	rollbackCmd.Flags().Bool("dry-run", false, "Show diffs without writing to Vault")
+	rollbackCmd.Flags().Bool("yes", false, "Auto-approve all rollbacks without prompting")
+
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 
 	"github.com/spf13/cobra"
+	"vault-sync/internal/codec"
 	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
 	"vault-sync/internal/logger"
+	"vault-sync/internal/scope"
+	"vault-sync/internal/vault"
 )
 
 var cfg *config.Config
@@ -16,10 +22,123 @@ var rootCmd = &cobra.Command{
 	Long: `vault-sync is a CLI tool that allows you to synchronize Vault KV v2 secrets
 with your local filesystem. It supports bidirectional sync with pull and push operations,
 Vault namespaces, and provides human-readable diffs for changes.`,
+	// Errors are reported by main, and usage is only useful for flag errors.
+	SilenceErrors: true,
+	// Flags and arguments have been parsed and validated by the time this
+	// runs, so errors from here on are not about how the command was invoked.
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return nil
+	},
 }
 
-func Execute() error {
-	return rootCmd.Execute()
+// Execute runs the root command. ctx is cancelled on interrupt and is
+// available to every command through cmd.Context().
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// newClient creates a Vault client for c and checks its KV mount, so that a
+// missing mount or a wrong KV version fails before any work is done.
+func newClient(ctx context.Context, c *config.Config) (*vault.Client, error) {
+	if c.MultiMount() {
+		return nil, errors.New("check_kv_mount",
+			fmt.Errorf("--kv-mount %q selects several mounts, which only pull and push support", c.KVMount))
+	}
+
+	client, err := vault.NewClient(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.DetectMount(ctx); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// newCodec creates the codec for target c of forEachTarget. Transit
+// encryption uses the key in the namespace the user configured, not in each
+// namespace synced by --recurse-namespaces.
+func newCodec(c *config.Config, client *vault.Client) (codec.Codec, error) {
+	if c.Encryption == config.EncryptionTransit && c.VaultNamespace != cfg.VaultNamespace {
+		transitClient, err := vault.NewClient(cfg)
+		if err != nil {
+			return nil, err
+		}
+		client = transitClient
+	}
+	return codec.New(c, client)
+}
+
+// forEachTarget runs fn once per namespace and mount selected by
+// --recurse-namespaces and --kv-mount, with a config that syncs the
+// directory of that namespace and mount, or once with cfg when neither
+// selects several. local selects them by their local directories, as for
+// push.
+func forEachTarget(ctx context.Context, local bool, fn func(c *config.Config) error) error {
+	if !cfg.RecurseNamespaces && !cfg.MultiMount() {
+		return fn(cfg)
+	}
+
+	namespaceConfigs := []*config.Config{cfg}
+	if cfg.RecurseNamespaces {
+		client, err := vault.NewClient(cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+		if namespaceConfigs, err = scope.Namespaces(ctx, client, cfg, local); err != nil {
+			return err
+		}
+	}
+
+	started, synced := 0, 0
+	for _, namespaceCfg := range namespaceConfigs {
+		targets := []*config.Config{namespaceCfg}
+		if namespaceCfg.MultiMount() {
+			client, err := vault.NewClient(namespaceCfg)
+			if err != nil {
+				return errors.Wrap(err, "create_vault_client")
+			}
+			if targets, err = scope.Mounts(ctx, client, namespaceCfg, local); err != nil {
+				return err
+			}
+		}
+
+		for _, target := range targets {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if started > 0 {
+				fmt.Println()
+			}
+			started++
+			if cfg.RecurseNamespaces {
+				fmt.Printf("== Namespace %s, mount %s ==\n", target.VaultNamespace, target.KVMount)
+			} else {
+				fmt.Printf("== Mount %s ==\n", target.KVMount)
+			}
+
+			err := fn(target)
+			if err != nil && cfg.RecurseNamespaces && vault.IsMountNotFound(err) {
+				// Not every namespace has every mount
+				fmt.Printf("- No mount %s in namespace %s, skipping\n", target.KVMount, target.VaultNamespace)
+				continue
+			}
+			if err != nil {
+				return errors.WrapWithPath(err, "sync_target", target.OutputDir)
+			}
+			synced++
+		}
+	}
+
+	if synced == 0 {
+		return errors.New("select_targets", fmt.Errorf("found no namespace and mount to sync")).
+			WithContext("namespace", cfg.VaultNamespace).
+			WithContext("kv_mount", cfg.KVMount).
+			WithContext("output_dir", cfg.OutputDir)
+	}
+	return nil
 }
 
 func init() {
@@ -31,9 +150,17 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfg.VaultAddr, "vault-addr", cfg.VaultAddr, "Vault server address (default: $VAULT_ADDR or http://localhost:8200)")
 	rootCmd.PersistentFlags().StringVar(&cfg.VaultToken, "vault-token", cfg.VaultToken, "Vault authentication token (default: $VAULT_TOKEN)")
 	rootCmd.PersistentFlags().StringVar(&cfg.VaultNamespace, "vault-namespace", cfg.VaultNamespace, "Vault namespace (default: $VAULT_NAMESPACE)")
-	rootCmd.PersistentFlags().StringVar(&cfg.KVMount, "kv-mount", cfg.KVMount, "KV v2 mount name")
+	rootCmd.PersistentFlags().StringVar(&cfg.KVMount, "kv-mount", cfg.KVMount, "KV mount name; for pull and push also a comma-separated list or '*' for all KV mounts")
+	rootCmd.PersistentFlags().IntVar(&cfg.KVVersion, "kv-version", cfg.KVVersion, "KV secrets engine version of the mount: 1 or 2 (default: detected from the mount)")
 	rootCmd.PersistentFlags().StringVar(&cfg.BasePath, "base-path", cfg.BasePath, "Base path in Vault to sync from")
 	rootCmd.PersistentFlags().StringVar(&cfg.OutputDir, "output-dir", cfg.OutputDir, "Local directory to sync to (default: ~/.vault-sync)")
+	rootCmd.PersistentFlags().StringVar(&cfg.Encryption, "encryption", cfg.Encryption, "Encryption for local secret files: none, age, sops or transit (default: $VAULT_SYNC_ENCRYPTION)")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.AgeRecipients, "age-recipient", cfg.AgeRecipients, "age public key to encrypt local files to, repeatable (default: $VAULT_SYNC_AGE_RECIPIENTS)")
+	rootCmd.PersistentFlags().StringVar(&cfg.AgeIdentityFile, "age-identity", cfg.AgeIdentityFile, "age identity file used to decrypt local files (default: $VAULT_SYNC_AGE_IDENTITY)")
+	rootCmd.PersistentFlags().StringVar(&cfg.SOPSFormat, "sops-format", cfg.SOPSFormat, "File format for sops encryption: yaml or json")
+	rootCmd.PersistentFlags().StringSliceVar(&cfg.PGPFingerprints, "pgp-fingerprint", cfg.PGPFingerprints, "PGP key fingerprint for sops encryption, repeatable (default: $SOPS_PGP_FP)")
+	rootCmd.PersistentFlags().StringVar(&cfg.TransitMount, "transit-mount", cfg.TransitMount, "Transit secrets engine mount for transit encryption")
+	rootCmd.PersistentFlags().StringVar(&cfg.TransitKey, "transit-key", cfg.TransitKey, "Transit key name for transit encryption (default: $VAULT_SYNC_TRANSIT_KEY)")
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Verbose, "verbose", "v", cfg.Verbose, "Enable verbose logging")
 	
 	// Add log level flag
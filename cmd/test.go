@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"vault-sync/internal/errors"
 	"vault-sync/internal/logger"
-	"vault-sync/internal/vault"
 )
 
 var testCmd = &cobra.Command{
@@ -16,14 +14,14 @@ var testCmd = &cobra.Command{
 	Long: `Test command to verify Vault connectivity, authentication, and KV mount accessibility.
 This command will attempt to connect to Vault and list the root of the KV mount to validate configuration.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
+		ctx := cmd.Context()
 		logger.InfoCtx(ctx, "Starting connectivity test")
 		
 		if err := cfg.Validate(); err != nil {
 			return errors.Wrap(err, "validate_config")
 		}
 
-		client, err := vault.NewClient(cfg)
+		client, err := newClient(ctx, cfg)
 		if err != nil {
 			return errors.Wrap(err, "create_vault_client")
 		}
@@ -33,9 +31,19 @@ This command will attempt to connect to Vault and list the root of the KV mount
 			fmt.Printf("✓ Using namespace: %s\n", cfg.VaultNamespace)
 		}
 		
+		if mount := client.Mount(); mount != nil {
+			fmt.Printf("✓ Found mount '%s': %s secrets engine, KV version %d\n", mount.Path, mount.Type, mount.KVVersion)
+			if mount.Description != "" {
+				fmt.Printf("  Description: %s\n", mount.Description)
+			}
+			fmt.Printf("  Detected from: %s\n", mount.Source)
+		} else {
+			fmt.Printf("- Mount details cannot be read with this token, assuming KV version %d\n", client.KVVersion())
+		}
+		
 		// Test listing the root of the KV mount
-		logger.InfoCtx(ctx, "Testing KV mount accessibility", "mount", cfg.KVMount)
-		fmt.Printf("Testing KV v2 mount '%s'...\n", cfg.KVMount)
+		logger.InfoCtx(ctx, "Testing KV mount accessibility", "mount", cfg.KVMount, "kv_version", client.KVVersion())
+		fmt.Printf("Testing KV v%d mount '%s'...\n", client.KVVersion(), cfg.KVMount)
 		
 		secrets, err := client.ListSecrets(ctx, cfg.BasePath)
 		if err != nil {
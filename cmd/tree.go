@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"vault-sync/internal/browse"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree [path]",
+	Short: "Show the secrets below a path as a tree",
+	Long: `Walks a path of the KV mount (--base-path or the mount root if no path is given) and
+prints its secrets as a tree. --depth limits how many levels are walked; directories
+below the limit are shown with "…". With --long, the key count, current version and
+update time of every secret are shown as well; this reads each secret and its
+metadata. --match keeps only secrets matching a glob, and --format json prints the
+entries as a flat JSON list.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+
+		basePath := cfg.BasePath
+		if len(args) == 1 {
+			basePath = args[0]
+		}
+		opts := browseOptions(cmd)
+
+		logger.InfoCtx(ctx, "Starting tree command",
+			"path", basePath,
+			"depth", opts.Depth,
+			"match", opts.Match,
+			"long", opts.Long,
+			"format", opts.Format)
+
+		if opts.Depth < 0 {
+			return fmt.Errorf("--depth must not be negative")
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return errors.Wrap(err, "validate_config")
+		}
+
+		client, err := newClient(ctx, cfg)
+		if err != nil {
+			return errors.Wrap(err, "create_vault_client")
+		}
+
+		return browse.New(client, cfg).Tree(ctx, basePath, opts)
+	},
+}
+
+func init() {
+	addBrowseFlags(treeCmd)
+	treeCmd.Flags().Int("depth", 0, "Number of levels to walk (0 for no limit)")
+
+	rootCmd.AddCommand(treeCmd)
+}
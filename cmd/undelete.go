@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"vault-sync/internal/deletion"
+)
+
+var undeleteCmd = &cobra.Command{
+	Use:   "undelete <path>",
+	Short: "Restore soft-deleted secret versions in Vault",
+	Long: `Restores the current version of a secret, or the versions given with --versions,
+after they were soft-deleted. Destroyed versions cannot be restored.
+
+With --recursive, every secret under the path is restored. The planned changes are
+listed and confirmed once before anything is written (unless --yes is used).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDeletion(cmd, args[0], deletion.ActionUndelete)
+	},
+}
+
+func init() {
+	addDeletionFlags(undeleteCmd)
+
+	rootCmd.AddCommand(undeleteCmd)
+}
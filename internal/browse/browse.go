@@ -0,0 +1,357 @@
+package browse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/vault"
+)
+
+// Output formats of List and Tree.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options select and describe the entries listed.
+type Options struct {
+	// Depth limits how many levels below the path are listed; 0 means no
+	// limit.
+	Depth int
+	// Match is a glob such as "db-*" matched against entry names, or
+	// against paths relative to the listed path when it contains a slash.
+	Match string
+	// Long adds the key count and version information of every secret,
+	// which costs two requests per secret.
+	Long   bool
+	Format string
+}
+
+// Entry is a secret, or a directory that was not descended into.
+type Entry struct {
+	// Path is the full path in the mount; directories end in a slash.
+	Path string `json:"path"`
+	Type string `json:"type"`
+	// The fields below are only set with Options.Long.
+	Keys    *int       `json:"keys,omitempty"`
+	Version int64      `json:"version,omitempty"`
+	Updated *time.Time `json:"updated_time,omitempty"`
+	Deleted bool       `json:"deleted,omitempty"`
+}
+
+// Entry types.
+const (
+	TypeSecret    = "secret"
+	TypeDirectory = "directory"
+)
+
+type Browser struct {
+	client *vault.Client
+	config *config.Config
+	out    io.Writer
+}
+
+func New(client *vault.Client, cfg *config.Config) *Browser {
+	return &Browser{
+		client: client,
+		config: cfg,
+		out:    os.Stdout,
+	}
+}
+
+// List prints the secrets and directories directly below basePath, like
+// ls.
+func (b *Browser) List(ctx context.Context, basePath string, opts Options) error {
+	opts.Depth = 1
+	entries, err := b.entries(ctx, basePath, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == FormatJSON {
+		return b.printJSON(entries)
+	}
+
+	if !opts.Long {
+		for _, entry := range entries {
+			fmt.Fprintln(b.out, relative(basePath, entry.Path))
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(b.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKEYS\tVERSION\tUPDATED")
+	for _, entry := range entries {
+		keys, version, updated := describe(entry)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", relative(basePath, entry.Path), keys, version, updated)
+	}
+	return w.Flush()
+}
+
+// Tree prints the secrets below basePath as a tree, like tree(1).
+func (b *Browser) Tree(ctx context.Context, basePath string, opts Options) error {
+	entries, err := b.entries(ctx, basePath, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Format == FormatJSON {
+		return b.printJSON(entries)
+	}
+
+	root := &node{children: make(map[string]*node)}
+	for _, entry := range entries {
+		root.add(nodeNames(relative(basePath, entry.Path)), entry)
+	}
+
+	title := path.Join(b.config.KVMount, basePath)
+	if b.config.VaultNamespace != "" {
+		title = b.config.VaultNamespace + ": " + title
+	}
+	fmt.Fprintln(b.out, title)
+	root.print(b.out, "", opts.Long)
+
+	directories, secrets := root.count()
+	fmt.Fprintf(b.out, "\n%d directories, %d secrets\n", directories, secrets)
+	return nil
+}
+
+// entries walks basePath up to opts.Depth levels and returns the matching
+// entries sorted by path.
+func (b *Browser) entries(ctx context.Context, basePath string, opts Options) ([]Entry, error) {
+	start := time.Now()
+	basePath = strings.Trim(basePath, "/")
+
+	if opts.Format != "" && opts.Format != FormatText && opts.Format != FormatJSON {
+		return nil, errors.New("parse_format", fmt.Errorf("unknown format %q, use text or json", opts.Format))
+	}
+	if _, err := path.Match(opts.Match, ""); err != nil {
+		return nil, errors.New("parse_match", err).WithContext("match", opts.Match)
+	}
+
+	logger.InfoCtx(ctx, "Listing secrets",
+		"path", basePath,
+		"depth", opts.Depth,
+		"match", opts.Match,
+		"long", opts.Long)
+
+	var entries []Entry
+	err := b.client.WalkSecretsDepth(ctx, basePath, opts.Depth, func(entryPath string) error {
+		if opts.Match != "" && !matches(opts.Match, relative(basePath, entryPath)) {
+			return nil
+		}
+
+		entry := Entry{Path: entryPath, Type: TypeSecret}
+		if strings.HasSuffix(entryPath, "/") {
+			entry.Type = TypeDirectory
+		} else if opts.Long {
+			if err := b.describeSecret(ctx, &entry); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		switch {
+		case !vault.IsNotFound(err):
+			return nil, errors.WrapWithPath(err, "list_secrets", basePath)
+		case basePath != "":
+			return nil, errors.NewWithPath("list_secrets", basePath, fmt.Errorf("no secrets found at this path")).
+				WithContext("mount", b.config.KVMount)
+		}
+		// An empty mount has nothing to list at its root
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	logger.DebugCtx(ctx, "Listed secrets",
+		"path", basePath,
+		"count", len(entries),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return entries, nil
+}
+
+// describeSecret adds the key count and version information of a secret.
+// KV v1 has no metadata, so only the key count is known there.
+func (b *Browser) describeSecret(ctx context.Context, entry *Entry) error {
+	if b.client.KVVersion() == 2 {
+		metadata, err := b.client.ReadMetadata(ctx, entry.Path)
+		if err != nil {
+			return errors.WrapWithPath(err, "read_metadata", entry.Path)
+		}
+		entry.Version = metadata.CurrentVersion
+		if !metadata.UpdatedTime.IsZero() {
+			updated := metadata.UpdatedTime
+			entry.Updated = &updated
+		}
+		if current, ok := metadata.Version(metadata.CurrentVersion); ok && (current.Deleted() || current.Destroyed) {
+			// The latest version cannot be read, so its keys are unknown
+			entry.Deleted = true
+			return nil
+		}
+	}
+
+	secret, err := b.client.ReadSecret(ctx, entry.Path)
+	if err != nil {
+		return errors.WrapWithPath(err, "read_secret", entry.Path)
+	}
+	keys := len(secret.Data)
+	entry.Keys = &keys
+	return nil
+}
+
+func (b *Browser) printJSON(entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	output, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return errors.New("encode_output", err)
+	}
+	_, err = fmt.Fprintln(b.out, string(output))
+	return err
+}
+
+// matches reports whether the entry at relativePath matches pattern. A
+// pattern without a slash is matched against the entry's name only.
+func matches(pattern, relativePath string) bool {
+	target := strings.TrimSuffix(relativePath, "/")
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(target)
+	}
+	ok, _ := path.Match(pattern, target)
+	return ok
+}
+
+func relative(basePath, entryPath string) string {
+	if basePath == "" {
+		return entryPath
+	}
+	return strings.TrimPrefix(entryPath, strings.Trim(basePath, "/")+"/")
+}
+
+// describe returns the key count, version and update time columns of an
+// entry; unknown values are shown as "-".
+func describe(entry Entry) (string, string, string) {
+	keys, version, updated := "-", "-", "-"
+	if entry.Type == TypeDirectory {
+		return keys, version, updated
+	}
+	if entry.Keys != nil {
+		keys = fmt.Sprintf("%d", *entry.Keys)
+	}
+	if entry.Deleted {
+		keys = "deleted"
+	}
+	if entry.Version > 0 {
+		version = fmt.Sprintf("v%d", entry.Version)
+	}
+	if entry.Updated != nil {
+		updated = entry.Updated.Local().Format("2006-01-02 15:04")
+	}
+	return keys, version, updated
+}
+
+// node is a directory or secret in the printed tree. Children are keyed by
+// name, with a trailing slash for directories, as Vault allows a secret and
+// a directory of the same name side by side.
+type node struct {
+	entry    *Entry
+	children map[string]*node
+}
+
+// nodeNames splits a relative entry path into the keys of its nodes: the
+// directories leading to it, each with a trailing slash, and the entry.
+func nodeNames(relativePath string) []string {
+	dir := strings.HasSuffix(relativePath, "/")
+	parts := strings.Split(strings.TrimSuffix(relativePath, "/"), "/")
+	for i := range parts {
+		if i < len(parts)-1 || dir {
+			parts[i] += "/"
+		}
+	}
+	return parts
+}
+
+func (n *node) add(names []string, entry Entry) {
+	child, ok := n.children[names[0]]
+	if !ok {
+		child = &node{children: make(map[string]*node)}
+		n.children[names[0]] = child
+	}
+	if len(names) == 1 {
+		child.entry = &entry
+		return
+	}
+	child.add(names[1:], entry)
+}
+
+func (n *node) print(w io.Writer, prefix string, long bool) {
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i, name := range names {
+		child := n.children[name]
+		branch, indent := "├── ", "│   "
+		if i == len(names)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		label := name
+		switch {
+		case isDir(name) && child.entry != nil:
+			// Cut off by the depth limit
+			label += " …"
+		case isDir(name):
+		case long:
+			keys, version, updated := describe(*child.entry)
+			switch keys {
+			case "deleted":
+			case "1":
+				keys += " key"
+			default:
+				keys += " keys"
+			}
+			label += fmt.Sprintf("  (%s, %s, %s)", keys, version, updated)
+		}
+		fmt.Fprintln(w, prefix+branch+label)
+		child.print(w, prefix+indent, long)
+	}
+}
+
+func isDir(name string) bool {
+	return strings.HasSuffix(name, "/")
+}
+
+func (n *node) count() (int, int) {
+	var directories, secrets int
+	for name, child := range n.children {
+		if isDir(name) {
+			directories++
+		} else {
+			secrets++
+		}
+		d, s := child.count()
+		directories += d
+		secrets += s
+	}
+	return directories, secrets
+}
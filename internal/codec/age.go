@@ -0,0 +1,138 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// ageCodec stores the YAML representation of a secret encrypted with age.
+// Files are ASCII-armored so they stay diff- and git-friendly.
+type ageCodec struct {
+	inner      Codec
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+// NewAge builds an age codec from recipient public keys and an identity file.
+// When no recipients are given they are derived from the X25519 identities,
+// so a single key file is enough for a personal setup.
+func NewAge(recipientKeys []string, identityFile string) (Codec, error) {
+	identities, err := loadAgeIdentities(identityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := parseAgeRecipients(recipientKeys, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &ageCodec{
+		inner:      NewYAML(),
+		recipients: recipients,
+		identities: identities,
+	}
+
+	if len(c.recipients) == 0 && len(c.identities) == 0 {
+		return nil, fmt.Errorf("age encryption requires at least one recipient or an identity file")
+	}
+
+	return c, nil
+}
+
+func (c *ageCodec) Encode(ctx context.Context, data map[string]string) ([]byte, error) {
+	if len(c.recipients) == 0 {
+		return nil, fmt.Errorf("no age recipients configured")
+	}
+
+	plaintext, err := c.inner.Encode(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, c.recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("age encrypt: %w", err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, fmt.Errorf("age encrypt: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("age encrypt: %w", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, fmt.Errorf("age armor: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *ageCodec) Decode(ctx context.Context, raw []byte) (map[string]string, error) {
+	if len(c.identities) == 0 {
+		return nil, fmt.Errorf("no age identity configured for decryption")
+	}
+
+	r, err := age.Decrypt(armor.NewReader(bytes.NewReader(raw)), c.identities...)
+	if err != nil {
+		return nil, fmt.Errorf("age decrypt: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("age decrypt: %w", err)
+	}
+
+	return c.inner.Decode(ctx, plaintext)
+}
+
+func (c *ageCodec) Extension() string {
+	return ".yaml.age"
+}
+
+func loadAgeIdentities(identityFile string) ([]age.Identity, error) {
+	if identityFile == "" {
+		return nil, nil
+	}
+
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("open age identity file: %w", err)
+	}
+	defer f.Close()
+
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse age identity file %s: %w", identityFile, err)
+	}
+	return identities, nil
+}
+
+// parseAgeRecipients parses X25519 public keys, falling back to the
+// recipients of the given identities when no keys are configured.
+func parseAgeRecipients(keys []string, identities []age.Identity) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, key := range keys {
+		recipient, err := age.ParseX25519Recipient(key)
+		if err != nil {
+			return nil, fmt.Errorf("parse age recipient %q: %w", key, err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	if len(recipients) == 0 {
+		for _, identity := range identities {
+			if x, ok := identity.(*age.X25519Identity); ok {
+				recipients = append(recipients, x.Recipient())
+			}
+		}
+	}
+	return recipients, nil
+}
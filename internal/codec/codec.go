@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/vault"
+)
+
+// Codec converts secret data to and from the bytes stored in a local file.
+type Codec interface {
+	Encode(ctx context.Context, data map[string]string) ([]byte, error)
+	Decode(ctx context.Context, raw []byte) (map[string]string, error)
+	// Extension is the file suffix used for secrets written by this codec.
+	Extension() string
+}
+
+// Rewriter is implemented by codecs whose files carry settings of their own,
+// such as the key groups of a sops file, which Encode would drop. Reencode
+// writes data in place of an existing file, keeping those settings.
+type Rewriter interface {
+	Reencode(ctx context.Context, previous []byte, data map[string]string) ([]byte, error)
+}
+
+// ownExtensions maps the file extensions that a single encryption mode
+// writes to that mode. Plaintext and sops files share .yaml and .json.
+var ownExtensions = map[string]string{
+	".yaml.age":     config.EncryptionAge,
+	".yaml.transit": config.EncryptionTransit,
+}
+
+// EncryptionOf returns the encryption mode that wrote the file name, when
+// its extension belongs to a single mode.
+func EncryptionOf(name string) (string, bool) {
+	for ext, mode := range ownExtensions {
+		if strings.HasSuffix(name, ext) {
+			return mode, true
+		}
+	}
+	return "", false
+}
+
+// New returns the codec selected by cfg.Encryption. The Vault client is only
+// used by codecs that delegate cryptography to Vault.
+func New(cfg *config.Config, client *vault.Client) (Codec, error) {
+	switch cfg.Encryption {
+	case "", config.EncryptionNone:
+		return NewYAML(), nil
+	case config.EncryptionAge:
+		return NewAge(cfg.AgeRecipients, cfg.AgeIdentityFile)
+	case config.EncryptionSOPS:
+		return NewSOPS(cfg.SOPSFormat, cfg.AgeRecipients, cfg.AgeIdentityFile, cfg.PGPFingerprints)
+	case config.EncryptionTransit:
+		return NewTransit(client, cfg.TransitMount, cfg.TransitKey)
+	default:
+		return nil, fmt.Errorf("unknown encryption mode %q", cfg.Encryption)
+	}
+}
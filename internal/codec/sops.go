@@ -0,0 +1,740 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	sopsVersion           = "3.8.1"
+	sopsUnencryptedSuffix = "_unencrypted"
+	sopsNonceSize         = 32
+)
+
+var sopsValuePattern = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// sopsMACOnlyEncryptedInit starts the MAC of files with mac_only_encrypted,
+// as in sops, so that it never equals the MAC over all values.
+var sopsMACOnlyEncryptedInit = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0xb, 0xb, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+
+// sopsForeignKeyTypes are the key types sops supports besides age and PGP.
+// The data key cannot be encrypted for them here, so a file using them is
+// only rewritten with its own data key and their entries kept as they are.
+var sopsForeignKeyTypes = []string{"kms", "gcp_kms", "azure_kv", "hc_vault"}
+
+// sopsMetadata mirrors the "sops" block written by sops v3. Entries of other
+// key types are not decoded; rewriting a file keeps them unchanged.
+type sopsMetadata struct {
+	Age                     []sopsAgeKey  `yaml:"age,omitempty"`
+	LastModified            string        `yaml:"lastmodified"`
+	MAC                     string        `yaml:"mac"`
+	PGP                     []sopsPGPKey  `yaml:"pgp,omitempty"`
+	UnencryptedSuffix       string        `yaml:"unencrypted_suffix,omitempty"`
+	EncryptedSuffix         string        `yaml:"encrypted_suffix,omitempty"`
+	UnencryptedRegex        string        `yaml:"unencrypted_regex,omitempty"`
+	EncryptedRegex          string        `yaml:"encrypted_regex,omitempty"`
+	UnencryptedCommentRegex string        `yaml:"unencrypted_comment_regex,omitempty"`
+	EncryptedCommentRegex   string        `yaml:"encrypted_comment_regex,omitempty"`
+	MACOnlyEncrypted        bool          `yaml:"mac_only_encrypted,omitempty"`
+	KeyGroups               []interface{} `yaml:"key_groups,omitempty"`
+	Version                 string        `yaml:"version"`
+}
+
+type sopsAgeKey struct {
+	Recipient string `yaml:"recipient"`
+	Enc       string `yaml:"enc"`
+}
+
+type sopsPGPKey struct {
+	CreatedAt   string `yaml:"created_at"`
+	Enc         string `yaml:"enc"`
+	Fingerprint string `yaml:"fp"`
+}
+
+// check rejects sops settings this codec cannot honour.
+func (m *sopsMetadata) check() error {
+	if m.UnencryptedCommentRegex != "" || m.EncryptedCommentRegex != "" {
+		return fmt.Errorf("sops files using unencrypted_comment_regex or encrypted_comment_regex are not supported")
+	}
+	if len(m.KeyGroups) > 0 {
+		return fmt.Errorf("sops files using key_groups are not supported")
+	}
+	for _, pattern := range []string{m.UnencryptedRegex, m.EncryptedRegex} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex in sops metadata: %w", err)
+		}
+	}
+	return nil
+}
+
+// encrypts reports whether the value of a top-level key is encrypted, with
+// the same precedence of suffixes and regexes as sops.
+func (m *sopsMetadata) encrypts(key string) bool {
+	encrypted := true
+	if m.UnencryptedSuffix != "" && strings.HasSuffix(key, m.UnencryptedSuffix) {
+		encrypted = false
+	}
+	if m.EncryptedSuffix != "" {
+		encrypted = strings.HasSuffix(key, m.EncryptedSuffix)
+	}
+	if m.UnencryptedRegex != "" {
+		if matched, _ := regexp.MatchString(m.UnencryptedRegex, key); matched {
+			encrypted = false
+		}
+	}
+	if m.EncryptedRegex != "" {
+		encrypted, _ = regexp.MatchString(m.EncryptedRegex, key)
+	}
+	return encrypted
+}
+
+// sopsFile is a parsed sops file. The metadata node is kept so that
+// rewriting the file preserves entries the codec does not know.
+type sopsFile struct {
+	root         *yaml.Node
+	metadataNode *yaml.Node
+	metadata     *sopsMetadata
+}
+
+func parseSOPSFile(raw []byte) (*sopsFile, error) {
+	// JSON is a subset of YAML, so both formats parse into the same node tree
+	// while preserving key order, which the MAC depends on.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("parse sops file: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("sops file is not a mapping")
+	}
+
+	file := &sopsFile{root: doc.Content[0]}
+	for i := 0; i+1 < len(file.root.Content); i += 2 {
+		if file.root.Content[i].Value == "sops" {
+			file.metadataNode = file.root.Content[i+1]
+			file.metadata = &sopsMetadata{}
+			if err := file.metadataNode.Decode(file.metadata); err != nil {
+				return nil, fmt.Errorf("parse sops metadata: %w", err)
+			}
+		}
+	}
+	if file.metadata == nil {
+		return nil, fmt.Errorf("file has no sops metadata block")
+	}
+	return file, nil
+}
+
+// decrypt returns the values of the file and the order of their keys, after
+// verifying the MAC.
+func (f *sopsFile) decrypt(dataKey []byte) (map[string]string, []string, error) {
+	mac := newSOPSMAC(f.metadata)
+	data := make(map[string]string)
+	var keys []string
+	for i := 0; i+1 < len(f.root.Content); i += 2 {
+		key, valueNode := f.root.Content[i].Value, f.root.Content[i+1]
+		if key == "sops" {
+			continue
+		}
+		if valueNode.Kind != yaml.ScalarNode {
+			return nil, nil, fmt.Errorf("key %s: nested values are not supported", key)
+		}
+
+		value, valueType := valueNode.Value, strings.TrimPrefix(valueNode.ShortTag(), "!!")
+		encrypted := f.metadata.encrypts(key)
+		if encrypted {
+			var err error
+			value, valueType, err = sopsDecryptValue(valueNode.Value, dataKey, key+":")
+			if err != nil {
+				return nil, nil, fmt.Errorf("decrypt key %s: %w", key, err)
+			}
+		}
+
+		mac.add(value, valueType, encrypted)
+		// sops encrypts booleans as "True" or "False" but shows them as YAML
+		// booleans when decrypting
+		if valueType == "bool" {
+			if b, err := strconv.ParseBool(value); err == nil {
+				value = strconv.FormatBool(b)
+			}
+		}
+		data[key] = value
+		keys = append(keys, key)
+	}
+
+	expected, _, err := sopsDecryptValue(f.metadata.MAC, dataKey, f.metadata.LastModified)
+	if err != nil {
+		return nil, nil, fmt.Errorf("decrypt sops mac: %w", err)
+	}
+	if expected != mac.sum() {
+		return nil, nil, fmt.Errorf("sops MAC mismatch: file was modified without re-encrypting")
+	}
+	return data, keys, nil
+}
+
+// foreignKeyTypes returns the key types of the file the data key cannot be
+// encrypted for here.
+func (f *sopsFile) foreignKeyTypes() []string {
+	var entries map[string]interface{}
+	if err := f.metadataNode.Decode(&entries); err != nil {
+		return nil
+	}
+	var types []string
+	for _, keyType := range sopsForeignKeyTypes {
+		if list, ok := entries[keyType].([]interface{}); ok && len(list) > 0 {
+			types = append(types, keyType)
+		}
+	}
+	return types
+}
+
+// sopsMAC computes the MAC sops keeps over the values of a file.
+type sopsMAC struct {
+	hash          hash.Hash
+	onlyEncrypted bool
+}
+
+func newSOPSMAC(metadata *sopsMetadata) *sopsMAC {
+	m := &sopsMAC{hash: sha512.New(), onlyEncrypted: metadata.MACOnlyEncrypted}
+	if m.onlyEncrypted {
+		m.hash.Write(sopsMACOnlyEncryptedInit)
+	}
+	return m
+}
+
+func (m *sopsMAC) add(value, valueType string, encrypted bool) {
+	if m.onlyEncrypted && !encrypted {
+		return
+	}
+	m.hash.Write(sopsMACBytes(value, valueType))
+}
+
+func (m *sopsMAC) sum() string {
+	return fmt.Sprintf("%X", m.hash.Sum(nil))
+}
+
+// sopsCodec reads and writes SOPS-compatible files: keys stay in plaintext,
+// values are encrypted with a per-file data key which is itself encrypted to
+// age recipients and/or PGP keys in the sops metadata block.
+type sopsCodec struct {
+	format          string
+	ageRecipients   []age.Recipient
+	ageIdentities   []age.Identity
+	pgpFingerprints []string
+}
+
+// NewSOPS builds a SOPS codec writing the given format ("yaml" or "json").
+// Age identities fall back to the locations sops itself uses
+// ($SOPS_AGE_KEY, $SOPS_AGE_KEY_FILE, ~/.config/sops/age/keys.txt).
+func NewSOPS(format string, ageRecipientKeys []string, ageIdentityFile string, pgpFingerprints []string) (Codec, error) {
+	if format != "yaml" && format != "json" {
+		return nil, fmt.Errorf("unsupported sops format %q (expected yaml or json)", format)
+	}
+
+	identities, err := loadSOPSAgeIdentities(ageIdentityFile)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := parseAgeRecipients(ageRecipientKeys, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recipients) == 0 && len(identities) == 0 && len(pgpFingerprints) == 0 {
+		return nil, fmt.Errorf("sops encryption requires an age recipient, an age identity or a PGP fingerprint")
+	}
+
+	return &sopsCodec{
+		format:          format,
+		ageRecipients:   recipients,
+		ageIdentities:   identities,
+		pgpFingerprints: pgpFingerprints,
+	}, nil
+}
+
+func (c *sopsCodec) Encode(ctx context.Context, data map[string]string) ([]byte, error) {
+	if len(c.ageRecipients) == 0 && len(c.pgpFingerprints) == 0 {
+		return nil, fmt.Errorf("no sops recipients configured")
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("generate sops data key: %w", err)
+	}
+
+	metadata := &sopsMetadata{
+		UnencryptedSuffix: sopsUnencryptedSuffix,
+		Version:           sopsVersion,
+	}
+	if _, err := c.addRecipients(ctx, metadata, nil, dataKey); err != nil {
+		return nil, err
+	}
+
+	var metadataNode yaml.Node
+	if err := metadataNode.Encode(metadata); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return c.seal(ctx, keys, data, dataKey, metadata, &metadataNode)
+}
+
+// Reencode writes data in place of a previous sops file. The file keeps its
+// data key, key order, encryption rules and all metadata entries, including
+// key types such as kms that cannot be written here, so everyone who could
+// decrypt it still can; configured recipients it lacks are added. If the
+// data is unchanged and no recipient is missing, the file is returned as is.
+func (c *sopsCodec) Reencode(ctx context.Context, previous []byte, data map[string]string) ([]byte, error) {
+	file, err := parseSOPSFile(previous)
+	if err != nil {
+		// Not a sops file, e.g. written with another encryption mode
+		return c.Encode(ctx, data)
+	}
+	if err := file.metadata.check(); err != nil {
+		return nil, fmt.Errorf("cannot rewrite sops file: %w", err)
+	}
+
+	dataKey, err := c.decryptDataKey(ctx, file.metadata)
+	if err != nil {
+		if foreign := file.foreignKeyTypes(); len(foreign) > 0 {
+			return nil, fmt.Errorf("cannot rewrite sops file: it is also encrypted with %s, which would be lost: %w",
+				strings.Join(foreign, ", "), err)
+		}
+		return c.Encode(ctx, data)
+	}
+
+	added, err := c.addRecipients(ctx, file.metadata, file.metadataNode, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	current, previousKeys, err := file.decrypt(dataKey)
+	if err == nil && !added && maps.Equal(current, data) {
+		return previous, nil
+	}
+
+	// Keys keep their place in the file; new keys are appended in order
+	var keys []string
+	for _, k := range previousKeys {
+		if _, ok := data[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	var newKeys []string
+	for k := range data {
+		if !slices.Contains(keys, k) {
+			newKeys = append(newKeys, k)
+		}
+	}
+	sort.Strings(newKeys)
+	keys = append(keys, newKeys...)
+
+	return c.seal(ctx, keys, data, dataKey, file.metadata, file.metadataNode)
+}
+
+// addRecipients encrypts dataKey to the configured age recipients and PGP
+// keys that metadata does not list yet, adding them to metadata and, if
+// given, to metadataNode. It reports whether any were added.
+func (c *sopsCodec) addRecipients(ctx context.Context, metadata *sopsMetadata, metadataNode *yaml.Node, dataKey []byte) (bool, error) {
+	added := false
+
+	for _, recipient := range c.ageRecipients {
+		name := fmt.Sprint(recipient)
+		if slices.ContainsFunc(metadata.Age, func(k sopsAgeKey) bool { return k.Recipient == name }) {
+			continue
+		}
+		enc, err := ageEncryptDataKey(dataKey, recipient)
+		if err != nil {
+			return false, err
+		}
+		key := sopsAgeKey{Recipient: name, Enc: enc}
+		metadata.Age = append(metadata.Age, key)
+		if metadataNode != nil {
+			if err := appendMappingSequence(metadataNode, "age", key); err != nil {
+				return false, err
+			}
+		}
+		added = true
+	}
+
+	for _, fp := range c.pgpFingerprints {
+		if slices.ContainsFunc(metadata.PGP, func(k sopsPGPKey) bool { return strings.EqualFold(k.Fingerprint, fp) }) {
+			continue
+		}
+		enc, err := gpgEncryptDataKey(ctx, dataKey, fp)
+		if err != nil {
+			return false, err
+		}
+		key := sopsPGPKey{
+			CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+			Enc:         enc,
+			Fingerprint: fp,
+		}
+		metadata.PGP = append(metadata.PGP, key)
+		if metadataNode != nil {
+			if err := appendMappingSequence(metadataNode, "pgp", key); err != nil {
+				return false, err
+			}
+		}
+		added = true
+	}
+
+	return added, nil
+}
+
+// seal encrypts data with dataKey following the rules in metadata, stores
+// the new MAC in metadataNode and renders the file with the values in key
+// order. JSON is always written with sorted keys.
+func (c *sopsCodec) seal(ctx context.Context, keys []string, data map[string]string, dataKey []byte, metadata *sopsMetadata, metadataNode *yaml.Node) ([]byte, error) {
+	if c.format == "json" {
+		keys = slices.Clone(keys)
+		sort.Strings(keys)
+	}
+
+	mac := newSOPSMAC(metadata)
+	values := make(map[string]string, len(data))
+	for _, k := range keys {
+		encrypted := metadata.encrypts(k)
+		mac.add(data[k], "str", encrypted)
+
+		if !encrypted {
+			values[k] = data[k]
+			continue
+		}
+
+		encryptedValue, err := sopsEncryptValue(data[k], dataKey, k+":")
+		if err != nil {
+			return nil, fmt.Errorf("encrypt key %s: %w", k, err)
+		}
+		values[k] = encryptedValue
+	}
+
+	lastModified := time.Now().UTC().Format(time.RFC3339)
+	encryptedMAC, err := sopsEncryptValue(mac.sum(), dataKey, lastModified)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt sops mac: %w", err)
+	}
+	setMappingString(metadataNode, "lastmodified", lastModified)
+	setMappingString(metadataNode, "mac", encryptedMAC)
+
+	if c.format == "json" {
+		var metadataValue interface{}
+		if err := metadataNode.Decode(&metadataValue); err != nil {
+			return nil, err
+		}
+		doc := make(map[string]interface{}, len(values)+1)
+		for k, v := range values {
+			doc[k] = v
+		}
+		doc["sops"] = metadataValue
+		out, err := json.MarshalIndent(doc, "", "\t")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, k := range keys {
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: values[k]})
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "sops"}, metadataNode)
+
+	return yaml.Marshal(root)
+}
+
+func (c *sopsCodec) Decode(ctx context.Context, raw []byte) (map[string]string, error) {
+	file, err := parseSOPSFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.metadata.check(); err != nil {
+		return nil, err
+	}
+
+	dataKey, err := c.decryptDataKey(ctx, file.metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := file.decrypt(dataKey)
+	return data, err
+}
+
+func (c *sopsCodec) Extension() string {
+	return "." + c.format
+}
+
+// setMappingString sets key of a mapping node to a quoted string, adding the
+// key if it is missing.
+func setMappingString(mapping *yaml.Node, key, value string) {
+	valueNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = valueNode
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+}
+
+// appendMappingSequence appends value to the sequence at key of a mapping
+// node, creating the sequence if it is missing.
+func appendMappingSequence(mapping *yaml.Node, key string, value interface{}) error {
+	var item yaml.Node
+	if err := item.Encode(value); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			sequence := mapping.Content[i+1]
+			if sequence.Kind != yaml.SequenceNode {
+				return fmt.Errorf("sops metadata %s is not a list", key)
+			}
+			// An empty list is written as [] by sops
+			sequence.Style = 0
+			sequence.Content = append(sequence.Content, &item)
+			return nil
+		}
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{&item}})
+	return nil
+}
+
+func (c *sopsCodec) decryptDataKey(ctx context.Context, metadata *sopsMetadata) ([]byte, error) {
+	var lastErr error
+
+	if len(c.ageIdentities) > 0 {
+		for _, key := range metadata.Age {
+			r, err := age.Decrypt(armor.NewReader(strings.NewReader(key.Enc)), c.ageIdentities...)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			return io.ReadAll(r)
+		}
+	}
+
+	for _, key := range metadata.PGP {
+		dataKey, err := gpgDecryptDataKey(ctx, key.Enc)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return dataKey, nil
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("could not decrypt sops data key: %w", lastErr)
+	}
+	return nil, fmt.Errorf("could not decrypt sops data key: no matching age identity or PGP key")
+}
+
+func sopsEncryptValue(plaintext string, key []byte, additionalData string) (string, error) {
+	// sops leaves empty values unencrypted
+	if plaintext == "" {
+		return "", nil
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsNonceSize)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, sopsNonceSize)
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+
+	out := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	tagStart := len(out) - gcm.Overhead()
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(out[:tagStart]),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(out[tagStart:])), nil
+}
+
+func sopsDecryptValue(value string, key []byte, additionalData string) (string, string, error) {
+	if value == "" {
+		return "", "str", nil
+	}
+
+	matches := sopsValuePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return "", "", fmt.Errorf("value is not sops-encrypted")
+	}
+
+	encData, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return "", "", fmt.Errorf("decode data: %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(matches[2])
+	if err != nil {
+		return "", "", fmt.Errorf("decode iv: %w", err)
+	}
+	tag, err := base64.StdEncoding.DecodeString(matches[3])
+	if err != nil {
+		return "", "", fmt.Errorf("decode tag: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", "", err
+	}
+
+	plaintext, err := gcm.Open(nil, iv, append(encData, tag...), []byte(additionalData))
+	if err != nil {
+		return "", "", err
+	}
+
+	return string(plaintext), matches[4], nil
+}
+
+// sopsMACBytes returns the bytes sops feeds into the MAC for a value. sops
+// hashes typed values as it formats them: numbers in their shortest form and
+// booleans in Python style, "True" or "False".
+func sopsMACBytes(value, valueType string) []byte {
+	switch valueType {
+	case "int":
+		if n, err := strconv.Atoi(value); err == nil {
+			return []byte(strconv.Itoa(n))
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return []byte(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(value); err == nil {
+			if b {
+				return []byte("True")
+			}
+			return []byte("False")
+		}
+	}
+	return []byte(value)
+}
+
+func ageEncryptDataKey(dataKey []byte, recipient age.Recipient) (string, error) {
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		return "", fmt.Errorf("age encrypt data key: %w", err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		return "", fmt.Errorf("age encrypt data key: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("age encrypt data key: %w", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return "", fmt.Errorf("age armor data key: %w", err)
+	}
+	return buf.String(), nil
+}
+
+func gpgEncryptDataKey(ctx context.Context, dataKey []byte, fingerprint string) (string, error) {
+	args := []string{"--no-default-recipient", "--yes", "--encrypt", "-a", "-r", fingerprint, "--no-encrypt-to"}
+	if len(fingerprint) >= 16 {
+		args = append(args, "--trusted-key", fingerprint[len(fingerprint)-16:])
+	}
+
+	out, err := runGPG(ctx, dataKey, args...)
+	if err != nil {
+		return "", fmt.Errorf("gpg encrypt data key for %s: %w", fingerprint, err)
+	}
+	return string(out), nil
+}
+
+func gpgDecryptDataKey(ctx context.Context, enc string) ([]byte, error) {
+	out, err := runGPG(ctx, []byte(enc), "--use-agent", "-d")
+	if err != nil {
+		return nil, fmt.Errorf("gpg decrypt data key: %w", err)
+	}
+	return out, nil
+}
+
+func runGPG(ctx context.Context, stdin []byte, args ...string) ([]byte, error) {
+	binary := os.Getenv("SOPS_GPG_EXEC")
+	if binary == "" {
+		binary = "gpg"
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+func loadSOPSAgeIdentities(identityFile string) ([]age.Identity, error) {
+	if identityFile != "" {
+		return loadAgeIdentities(identityFile)
+	}
+
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		identities, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("parse SOPS_AGE_KEY: %w", err)
+		}
+		return identities, nil
+	}
+
+	if file := os.Getenv("SOPS_AGE_KEY_FILE"); file != "" {
+		return loadAgeIdentities(file)
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, nil
+	}
+	defaultFile := filepath.Join(configDir, "sops", "age", "keys.txt")
+	if _, err := os.Stat(defaultFile); err != nil {
+		return nil, nil
+	}
+	return loadAgeIdentities(defaultFile)
+}
@@ -0,0 +1,345 @@
+package codec
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"gopkg.in/yaml.v3"
+)
+
+// The files in testdata were encrypted by sops 3.9.0 to the age keys in
+// age-key.txt and other-age-key.txt, from this plaintext:
+//
+//	username: admin
+//	password: s3cr3t
+//	port: 5432
+//	enabled: true
+//	ratio: 1.50
+//	empty: ""
+//	note_unencrypted: visible
+//
+// sops-kms.yaml is sops.yaml with a kms entry added to its metadata, which
+// the MAC does not cover, standing in for a key group vault-sync cannot
+// write.
+
+func newTestSOPS(t *testing.T, format, identityFile string) *sopsCodec {
+	t.Helper()
+	c, err := NewSOPS(format, nil, identityFile, nil)
+	if err != nil {
+		t.Fatalf("NewSOPS: %v", err)
+	}
+	return c.(*sopsCodec)
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestSOPSDecodeFilesWrittenBySOPS(t *testing.T) {
+	full := map[string]string{
+		"username":         "admin",
+		"password":         "s3cr3t",
+		"port":             "5432",
+		"enabled":          "true",
+		"ratio":            "1.5",
+		"empty":            "",
+		"note_unencrypted": "visible",
+	}
+
+	tests := []struct {
+		file   string
+		format string
+		want   map[string]string
+	}{
+		{file: "sops.yaml", format: "yaml", want: full},
+		{file: "sops-kms.yaml", format: "yaml", want: full},
+		{file: "sops-encrypted-regex.yaml", format: "yaml", want: full},
+		{file: "sops-unencrypted-regex.yaml", format: "yaml", want: full},
+		{
+			file:   "sops.json",
+			format: "json",
+			want: map[string]string{
+				"username":         "admin",
+				"password":         "s3cr3t",
+				"empty":            "",
+				"note_unencrypted": "visible",
+			},
+		},
+		{
+			file:   "sops-mac-only-encrypted.yaml",
+			format: "yaml",
+			want: map[string]string{
+				"username":        "admin",
+				"password_secret": "s3cr3t",
+				"port":            "5432",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			for _, identity := range []string{"age-key.txt", "other-age-key.txt"} {
+				c := newTestSOPS(t, tt.format, filepath.Join("testdata", identity))
+				got, err := c.Decode(context.Background(), readTestdata(t, tt.file))
+				if err != nil {
+					t.Fatalf("Decode with %s: %v", identity, err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Decode with %s = %v, want %v", identity, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSOPSDecodeDetectsTampering(t *testing.T) {
+	raw := readTestdata(t, "sops-encrypted-regex.yaml")
+	// username is not encrypted there, but covered by the MAC
+	tampered := bytes.Replace(raw, []byte("username: admin"), []byte("username: root"), 1)
+
+	c := newTestSOPS(t, "yaml", filepath.Join("testdata", "age-key.txt"))
+	if _, err := c.Decode(context.Background(), tampered); err == nil || !strings.Contains(err.Error(), "MAC mismatch") {
+		t.Fatalf("Decode of tampered file: err = %v, want MAC mismatch", err)
+	}
+}
+
+func TestSOPSReencodeRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		file   string
+		format string
+	}{
+		{"sops.yaml", "yaml"},
+		{"sops.json", "json"},
+		{"sops-encrypted-regex.yaml", "yaml"},
+		{"sops-unencrypted-regex.yaml", "yaml"},
+		{"sops-mac-only-encrypted.yaml", "yaml"},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			previous := readTestdata(t, tt.file)
+			c := newTestSOPS(t, tt.format, filepath.Join("testdata", "age-key.txt"))
+
+			data, err := c.Decode(ctx, previous)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			data["username"] = "operator"
+			data["added_key"] = "new value"
+
+			out, err := c.Reencode(ctx, previous, data)
+			if err != nil {
+				t.Fatalf("Reencode: %v", err)
+			}
+
+			// The other holder can still decrypt the rewritten file
+			other := newTestSOPS(t, tt.format, filepath.Join("testdata", "other-age-key.txt"))
+			got, err := other.Decode(ctx, out)
+			if err != nil {
+				t.Fatalf("Decode of rewritten file with other key: %v\n%s", err, out)
+			}
+			if !reflect.DeepEqual(got, data) {
+				t.Errorf("Decode of rewritten file = %v, want %v", got, data)
+			}
+
+			// The encryption rules of the file are kept
+			before, _ := parseSOPSFile(previous)
+			after, err := parseSOPSFile(out)
+			if err != nil {
+				t.Fatalf("parse rewritten file: %v", err)
+			}
+			if before.metadata.EncryptedRegex != after.metadata.EncryptedRegex ||
+				before.metadata.UnencryptedRegex != after.metadata.UnencryptedRegex ||
+				before.metadata.EncryptedSuffix != after.metadata.EncryptedSuffix ||
+				before.metadata.UnencryptedSuffix != after.metadata.UnencryptedSuffix ||
+				before.metadata.MACOnlyEncrypted != after.metadata.MACOnlyEncrypted {
+				t.Errorf("encryption rules changed:\nbefore %+v\nafter  %+v", before.metadata, after.metadata)
+			}
+			for k, v := range data {
+				stored := storedValue(t, after, k)
+				if encrypted := strings.HasPrefix(stored, "ENC["); encrypted != (after.metadata.encrypts(k) && v != "") {
+					t.Errorf("key %s stored as %q, encrypts = %v", k, stored, after.metadata.encrypts(k))
+				}
+			}
+		})
+	}
+}
+
+func TestSOPSReencodeUnchangedKeepsFile(t *testing.T) {
+	ctx := context.Background()
+	previous := readTestdata(t, "sops.yaml")
+	c := newTestSOPS(t, "yaml", filepath.Join("testdata", "age-key.txt"))
+
+	data, err := c.Decode(ctx, previous)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	out, err := c.Reencode(ctx, previous, data)
+	if err != nil {
+		t.Fatalf("Reencode: %v", err)
+	}
+	if !bytes.Equal(out, previous) {
+		t.Errorf("Reencode of unchanged data rewrote the file:\n%s", out)
+	}
+}
+
+func TestSOPSReencodeKeepsForeignKeyTypes(t *testing.T) {
+	ctx := context.Background()
+	previous := readTestdata(t, "sops-kms.yaml")
+	c := newTestSOPS(t, "yaml", filepath.Join("testdata", "age-key.txt"))
+
+	out, err := c.Reencode(ctx, previous, map[string]string{"username": "operator"})
+	if err != nil {
+		t.Fatalf("Reencode: %v", err)
+	}
+
+	before, _ := parseSOPSFile(previous)
+	after, err := parseSOPSFile(out)
+	if err != nil {
+		t.Fatalf("parse rewritten file: %v", err)
+	}
+	if got, want := metadataEntry(t, after, "kms"), metadataEntry(t, before, "kms"); !reflect.DeepEqual(got, want) {
+		t.Errorf("kms entries = %v, want %v", got, want)
+	}
+	if got := after.foreignKeyTypes(); !reflect.DeepEqual(got, []string{"kms"}) {
+		t.Errorf("foreignKeyTypes() = %v, want [kms]", got)
+	}
+}
+
+func TestSOPSReencodeRefusesToDropForeignKeyTypes(t *testing.T) {
+	ctx := context.Background()
+
+	// An identity that cannot decrypt the data key of the fixture
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	if err := os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := newTestSOPS(t, "yaml", keyFile)
+
+	_, err = c.Reencode(ctx, readTestdata(t, "sops-kms.yaml"), map[string]string{"username": "operator"})
+	if err == nil || !strings.Contains(err.Error(), "kms") {
+		t.Fatalf("Reencode without the data key: err = %v, want an error naming kms", err)
+	}
+
+	// Without foreign key types the file is simply written anew
+	out, err := c.Reencode(ctx, readTestdata(t, "sops.yaml"), map[string]string{"username": "operator"})
+	if err != nil {
+		t.Fatalf("Reencode of age-only file: %v", err)
+	}
+	if got, err := c.Decode(ctx, out); err != nil || got["username"] != "operator" {
+		t.Fatalf("Decode of new file = %v, %v", got, err)
+	}
+}
+
+func TestSOPSRejectsUnsupportedModes(t *testing.T) {
+	ctx := context.Background()
+	c := newTestSOPS(t, "yaml", filepath.Join("testdata", "age-key.txt"))
+
+	for _, extra := range []string{
+		"    encrypted_comment_regex: ^sops:enc\n",
+		"    unencrypted_comment_regex: ^sops:plain\n",
+		"    shamir_threshold: 2\n    key_groups:\n        - age: []\n",
+	} {
+		raw := bytes.Replace(readTestdata(t, "sops.yaml"), []byte("    version: 3.9.0\n"), []byte(extra+"    version: 3.9.0\n"), 1)
+
+		if _, err := c.Decode(ctx, raw); err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("Decode with %q: err = %v, want not supported", extra, err)
+		}
+		if _, err := c.Reencode(ctx, raw, map[string]string{"username": "operator"}); err == nil || !strings.Contains(err.Error(), "not supported") {
+			t.Errorf("Reencode with %q: err = %v, want not supported", extra, err)
+		}
+	}
+}
+
+// TestSOPSFilesReadBySOPS checks that files written here decrypt with the
+// sops binary, when it is installed.
+func TestSOPSFilesReadBySOPS(t *testing.T) {
+	binary, err := exec.LookPath("sops")
+	if err != nil {
+		t.Skip("sops binary not found in PATH")
+	}
+	ctx := context.Background()
+	keyFile, err := filepath.Abs(filepath.Join("testdata", "age-key.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{"yaml", "json"} {
+		t.Run(format, func(t *testing.T) {
+			c := newTestSOPS(t, format, keyFile)
+			data := map[string]string{
+				"username":         "admin",
+				"password":         "s3cr3t",
+				"port":             "5432",
+				"empty":            "",
+				"note_unencrypted": "visible",
+			}
+
+			written, err := c.Encode(ctx, data)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			data["password"] = "rotated"
+			rewritten, err := c.Reencode(ctx, written, data)
+			if err != nil {
+				t.Fatalf("Reencode: %v", err)
+			}
+
+			for name, raw := range map[string][]byte{"encoded": written, "reencoded": rewritten} {
+				file := filepath.Join(t.TempDir(), "secret."+format)
+				if err := os.WriteFile(file, raw, 0600); err != nil {
+					t.Fatal(err)
+				}
+				cmd := exec.Command(binary, "--decrypt", file)
+				cmd.Env = append(os.Environ(), "SOPS_AGE_KEY_FILE="+keyFile)
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("sops --decrypt of %s file: %v\n%s", name, err, out)
+				}
+				var got map[string]string
+				if err := yaml.Unmarshal(out, &got); err != nil {
+					t.Fatalf("parse sops output: %v\n%s", err, out)
+				}
+				if name == "reencoded" && got["password"] != "rotated" {
+					t.Errorf("sops --decrypt of %s file: password = %q, want rotated", name, got["password"])
+				}
+			}
+		})
+	}
+}
+
+func storedValue(t *testing.T, file *sopsFile, key string) string {
+	t.Helper()
+	for i := 0; i+1 < len(file.root.Content); i += 2 {
+		if file.root.Content[i].Value == key {
+			return file.root.Content[i+1].Value
+		}
+	}
+	t.Fatalf("key %s not in file", key)
+	return ""
+}
+
+func metadataEntry(t *testing.T, file *sopsFile, key string) interface{} {
+	t.Helper()
+	var entries map[string]interface{}
+	if err := file.metadataNode.Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	return entries[key]
+}
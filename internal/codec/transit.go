@@ -0,0 +1,103 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"vault-sync/internal/vault"
+)
+
+// transitCodec stores secrets as YAML whose values are Vault Transit
+// ciphertexts, so decrypting a local file requires access to the key. The
+// files get an extension of their own so that a plaintext push never takes
+// the ciphertexts for values.
+type transitCodec struct {
+	inner  Codec
+	client *vault.Client
+	mount  string
+	key    string
+}
+
+func NewTransit(client *vault.Client, mount, key string) (Codec, error) {
+	if key == "" {
+		return nil, fmt.Errorf("transit encryption requires a key name")
+	}
+	return &transitCodec{
+		inner:  NewYAML(),
+		client: client,
+		mount:  mount,
+		key:    key,
+	}, nil
+}
+
+func (c *transitCodec) Encode(ctx context.Context, data map[string]string) ([]byte, error) {
+	keys, plaintexts := c.nonEmpty(data)
+
+	ciphertexts, err := c.client.TransitEncrypt(ctx, c.mount, c.key, plaintexts)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted := make(map[string]string, len(data))
+	for k, v := range data {
+		encrypted[k] = v
+	}
+	for i, k := range keys {
+		encrypted[k] = ciphertexts[i]
+	}
+
+	return c.inner.Encode(ctx, encrypted)
+}
+
+func (c *transitCodec) Decode(ctx context.Context, raw []byte) (map[string]string, error) {
+	encrypted, err := c.inner.Decode(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, ciphertexts := c.nonEmpty(encrypted)
+	for i, ciphertext := range ciphertexts {
+		if !strings.HasPrefix(ciphertext, "vault:v") {
+			return nil, fmt.Errorf("value for key %s is not a Transit ciphertext", keys[i])
+		}
+	}
+
+	plaintexts, err := c.client.TransitDecrypt(ctx, c.mount, c.key, ciphertexts)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]string, len(encrypted))
+	for k, v := range encrypted {
+		data[k] = v
+	}
+	for i, k := range keys {
+		data[k] = plaintexts[i]
+	}
+
+	return data, nil
+}
+
+func (c *transitCodec) Extension() string {
+	return c.inner.Extension() + ".transit"
+}
+
+// nonEmpty returns the keys with non-empty values and those values, in key
+// order. Empty values are stored as-is since there is nothing to protect.
+func (c *transitCodec) nonEmpty(data map[string]string) ([]string, []string) {
+	var keys []string
+	for k, v := range data {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		values[i] = data[k]
+	}
+	return keys, values
+}
@@ -0,0 +1,30 @@
+package codec
+
+import (
+	"context"
+
+	"gopkg.in/yaml.v3"
+)
+
+type yamlCodec struct{}
+
+// NewYAML returns the default codec which stores secrets as plaintext YAML.
+func NewYAML() Codec {
+	return yamlCodec{}
+}
+
+func (yamlCodec) Encode(_ context.Context, data map[string]string) ([]byte, error) {
+	return yaml.Marshal(data)
+}
+
+func (yamlCodec) Decode(_ context.Context, raw []byte) (map[string]string, error) {
+	var data map[string]string
+	if err := yaml.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (yamlCodec) Extension() string {
+	return ".yaml"
+}
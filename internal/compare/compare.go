@@ -0,0 +1,171 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/vault"
+)
+
+type Comparer struct {
+	src       *vault.Client
+	srcConfig *config.Config
+	dst       *vault.Client
+	dstConfig *config.Config
+}
+
+func New(src *vault.Client, srcCfg *config.Config, dst *vault.Client, dstCfg *config.Config) *Comparer {
+	return &Comparer{
+		src:       src,
+		srcConfig: srcCfg,
+		dst:       dst,
+		dstConfig: dstCfg,
+	}
+}
+
+// Compare walks both locations and prints, per relative path, whether the
+// secret exists on one side only or which keys differ. Values are masked.
+// When neither location has secrets below it, both paths are compared as
+// single secrets.
+func (c *Comparer) Compare(ctx context.Context, showIdentical bool) error {
+	start := time.Now()
+	srcLocation := c.srcConfig.Location()
+	dstLocation := c.dstConfig.Location()
+
+	logger.InfoCtx(ctx, "Starting compare operation",
+		"source", srcLocation.String(),
+		"destination", dstLocation.String())
+
+	fmt.Printf("Comparing %s\n     with %s\n\n", srcLocation, dstLocation)
+
+	srcSecrets, err := c.collect(ctx, c.src, c.srcConfig.BasePath)
+	if err != nil {
+		return err
+	}
+	dstSecrets, err := c.collect(ctx, c.dst, c.dstConfig.BasePath)
+	if err != nil {
+		return err
+	}
+
+	if len(srcSecrets) == 0 && len(dstSecrets) == 0 {
+		if srcSecrets, err = c.readSingle(ctx, c.src, c.srcConfig.BasePath); err != nil {
+			return err
+		}
+		if dstSecrets, err = c.readSingle(ctx, c.dst, c.dstConfig.BasePath); err != nil {
+			return err
+		}
+	}
+
+	paths := make(map[string]bool)
+	for p := range srcSecrets {
+		paths[p] = true
+	}
+	for p := range dstSecrets {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var identical, different, onlySource, onlyDestination int
+	for _, p := range sorted {
+		srcData, inSource := srcSecrets[p]
+		dstData, inDestination := dstSecrets[p]
+		name := p
+		if name == "" {
+			name = strings.Trim(c.srcConfig.BasePath, "/")
+		}
+
+		switch {
+		case !inDestination:
+			onlySource++
+			fmt.Printf("< %s (only in source, %d keys)\n", name, len(srcData))
+		case !inSource:
+			onlyDestination++
+			fmt.Printf("> %s (only in destination, %d keys)\n", name, len(dstData))
+		default:
+			changes := diff.CompareKeys(srcData, dstData)
+			if len(changes) == 0 {
+				identical++
+				if showIdentical {
+					fmt.Printf("= %s\n", name)
+				}
+				continue
+			}
+			different++
+			fmt.Printf("~ %s (%d keys differ)\n", name, len(changes))
+			for _, change := range diff.MaskValues(changes) {
+				switch change.Type {
+				case diff.ChangeAdded:
+					fmt.Printf("    + %s (only in destination)\n", change.Key)
+				case diff.ChangeRemoved:
+					fmt.Printf("    - %s (only in source)\n", change.Key)
+				case diff.ChangeModified:
+					fmt.Printf("    ~ %s: %s -> %s\n", change.Key, change.OldValue, change.NewValue)
+				}
+			}
+		}
+	}
+
+	logger.InfoCtx(ctx, "Compare operation completed",
+		"identical", identical,
+		"different", different,
+		"only_source", onlySource,
+		"only_destination", onlyDestination,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	fmt.Printf("\n%d identical, %d different, %d only in source, %d only in destination\n",
+		identical, different, onlySource, onlyDestination)
+	return nil
+}
+
+// collect reads every secret below basePath, keyed by its path relative to
+// basePath. A base path that does not exist yields no secrets. Secrets whose
+// current version is deleted are left out.
+func (c *Comparer) collect(ctx context.Context, client *vault.Client, basePath string) (map[string]map[string]string, error) {
+	base := strings.Trim(basePath, "/")
+	secrets := make(map[string]map[string]string)
+
+	err := client.WalkSecrets(ctx, base, func(secretPath string) error {
+		secret, err := client.ReadSecret(ctx, secretPath)
+		if err != nil {
+			if vault.IsNotFound(err) {
+				return nil
+			}
+			return errors.WrapWithPath(err, "read_secret", secretPath)
+		}
+		relative := strings.TrimPrefix(strings.TrimPrefix(secretPath, base), "/")
+		secrets[relative] = secret.Data
+		return nil
+	})
+	if err != nil && !vault.IsNotFound(err) {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (c *Comparer) readSingle(ctx context.Context, client *vault.Client, secretPath string) (map[string]map[string]string, error) {
+	secrets := make(map[string]map[string]string)
+	if strings.Trim(secretPath, "/") == "" {
+		return secrets, nil
+	}
+
+	secret, err := client.ReadSecret(ctx, secretPath)
+	if err != nil {
+		if vault.IsNotFound(err) {
+			return secrets, nil
+		}
+		return nil, errors.WrapWithPath(err, "read_secret", secretPath)
+	}
+	secrets[""] = secret.Data
+	return secrets, nil
+}
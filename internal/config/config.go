@@ -4,36 +4,80 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"time"
+)
+
+// AllMounts as KVMount selects every KV mount in the namespace.
+const AllMounts = "*"
+
+const (
+	EncryptionNone    = "none"
+	EncryptionAge     = "age"
+	EncryptionSOPS    = "sops"
+	EncryptionTransit = "transit"
 )
 
 type Config struct {
-	VaultAddr      string
-	VaultToken     string
-	VaultNamespace string
-	KVMount        string
-	BasePath       string
-	OutputDir      string
-	DryRun         bool
-	AutoApprove    bool
-	Verbose        bool
-	LogLevel       slog.Level
+	VaultAddr       string
+	VaultToken      string
+	VaultNamespace  string
+	KVMount         string
+	KVVersion       int
+	BasePath        string
+	OutputDir       string
+	DryRun          bool
+	AutoApprove     bool
+	Resume          bool
+	Patch           bool
+	Interactive     bool
+	TUI             bool
+	SecretVersion   int64
+	AsOf            time.Time
+	Verbose         bool
+	LogLevel        slog.Level
+	Encryption      string
+	AgeRecipients   []string
+	AgeIdentityFile string
+	SOPSFormat      string
+	PGPFingerprints []string
+	TransitMount    string
+	TransitKey      string
+
+	// RecurseNamespaces syncs every namespace below VaultNamespace, each
+	// into the directory named after its relative path.
+	RecurseNamespaces bool
+	// ExcludeDirs names top-level entries of OutputDir that belong to
+	// another sync, such as the directories of child namespaces and the
+	// staging trees and journals kept next to them. Pull keeps them and
+	// push skips them.
+	ExcludeDirs []string
 }
 
 func New() *Config {
 	homeDir, _ := os.UserHomeDir()
 	
 	return &Config{
-		VaultAddr:      getEnvOrDefault("VAULT_ADDR", "http://localhost:8200"),
-		VaultToken:     getEnvOrDefault("VAULT_TOKEN", ""),
-		VaultNamespace: getEnvOrDefault("VAULT_NAMESPACE", ""),
-		KVMount:        "kv",
-		BasePath:       "",
-		OutputDir:      filepath.Join(homeDir, ".vault-sync"),
-		DryRun:         false,
-		AutoApprove:    false,
-		Verbose:        false,
-		LogLevel:       slog.LevelInfo,
+		VaultAddr:       getEnvOrDefault("VAULT_ADDR", "http://localhost:8200"),
+		VaultToken:      getEnvOrDefault("VAULT_TOKEN", ""),
+		VaultNamespace:  getEnvOrDefault("VAULT_NAMESPACE", ""),
+		KVMount:         "kv",
+		BasePath:        "",
+		OutputDir:       filepath.Join(homeDir, ".vault-sync"),
+		DryRun:          false,
+		AutoApprove:     false,
+		Resume:          false,
+		Verbose:         false,
+		LogLevel:        slog.LevelInfo,
+		Encryption:      getEnvOrDefault("VAULT_SYNC_ENCRYPTION", EncryptionNone),
+		AgeRecipients:   splitList(os.Getenv("VAULT_SYNC_AGE_RECIPIENTS")),
+		AgeIdentityFile: getEnvOrDefault("VAULT_SYNC_AGE_IDENTITY", ""),
+		SOPSFormat:      "yaml",
+		PGPFingerprints: splitList(os.Getenv("SOPS_PGP_FP")),
+		TransitMount:    "transit",
+		TransitKey:      getEnvOrDefault("VAULT_SYNC_TRANSIT_KEY", ""),
 	}
 }
 
@@ -47,15 +91,170 @@ func (c *Config) Validate() error {
 	if c.KVMount == "" {
 		return fmt.Errorf("KV mount is required")
 	}
+	if c.KVVersion < 0 || c.KVVersion > 2 {
+		return fmt.Errorf("KV version must be 1 or 2, got %d", c.KVVersion)
+	}
 	if c.OutputDir == "" {
 		return fmt.Errorf("output directory is required")
 	}
+	switch c.Encryption {
+	case "", EncryptionNone:
+	case EncryptionAge:
+		if len(c.AgeRecipients) == 0 && c.AgeIdentityFile == "" {
+			return fmt.Errorf("age encryption requires --age-recipient or --age-identity")
+		}
+	case EncryptionSOPS:
+		if c.SOPSFormat != "yaml" && c.SOPSFormat != "json" {
+			return fmt.Errorf("sops format must be yaml or json, got %q", c.SOPSFormat)
+		}
+	case EncryptionTransit:
+		if c.TransitKey == "" {
+			return fmt.Errorf("transit encryption requires --transit-key")
+		}
+	default:
+		return fmt.Errorf("unknown encryption mode %q (expected none, age, sops or transit)", c.Encryption)
+	}
 	return nil
 }
 
+// MultiMount reports whether KVMount selects several mounts: all of them
+// with "*", or a comma-separated list.
+func (c *Config) MultiMount() bool {
+	return c.KVMount == AllMounts || strings.Contains(c.KVMount, ",")
+}
+
+// MountList returns the mounts of a comma-separated KVMount.
+func (c *Config) MountList() []string {
+	var mounts []string
+	for _, mount := range splitList(c.KVMount) {
+		if mount = strings.Trim(mount, "/"); mount != "" {
+			mounts = append(mounts, mount)
+		}
+	}
+	return mounts
+}
+
+// ForMount returns a copy of c that syncs mount with the directory named
+// after the mount below the output directory.
+func (c *Config) ForMount(mount string) *Config {
+	clone := c.Clone()
+	clone.KVMount = mount
+	clone.OutputDir = filepath.Join(c.OutputDir, filepath.FromSlash(mount))
+	clone.ExcludeDirs = nil
+	return clone
+}
+
+// ForNamespace returns a copy of c that syncs the namespace at relative
+// below VaultNamespace with the directory of the same path below the
+// output directory.
+func (c *Config) ForNamespace(relative string) *Config {
+	clone := c.Clone()
+	clone.VaultNamespace = path.Join(c.VaultNamespace, relative)
+	clone.OutputDir = filepath.Join(c.OutputDir, filepath.FromSlash(relative))
+	clone.RecurseNamespaces = false
+	clone.ExcludeDirs = nil
+	return clone
+}
+
+// Location identifies a path in a KV mount on a Vault cluster.
+type Location struct {
+	Addr      string
+	Token     string
+	Namespace string
+	Mount     string
+	Path      string
+}
+
+// String formats the location as [namespace:]mount/path on addr, without
+// the token.
+func (l Location) String() string {
+	s := strings.Trim(l.Mount, "/") + "/" + strings.Trim(l.Path, "/")
+	if l.Namespace != "" {
+		s = strings.Trim(l.Namespace, "/") + ":" + s
+	}
+	return s + " on " + l.Addr
+}
+
+// ParseLocation parses a location of the form [namespace:]mount/path, for
+// example "team-a:kv/app/api". A ":" is only taken to end the namespace when
+// it comes before the first "/", so mounts and paths may contain ":" and a
+// leading ":" keeps the default namespace for a mount such as ":kv:v2/app".
+// Nested namespaces contain "/" and so are given through defaults. Anything
+// not given in spec, including the address and token, is taken from
+// defaults.
+func ParseLocation(spec string, defaults Location) (Location, error) {
+	loc := defaults
+	rest := spec
+	if i := strings.Index(spec, ":"); i >= 0 && !strings.Contains(spec[:i], "/") {
+		if namespace := spec[:i]; namespace != "" {
+			loc.Namespace = namespace
+		}
+		rest = spec[i+1:]
+	}
+
+	rest = strings.Trim(rest, "/")
+	if rest == "" {
+		return Location{}, fmt.Errorf("invalid location %q, expected [namespace:]mount/path", spec)
+	}
+
+	mount, path, _ := strings.Cut(rest, "/")
+	loc.Mount = mount
+	loc.Path = path
+	return loc, nil
+}
+
+// SameMount reports whether both locations refer to the same mount.
+func (l Location) SameMount(other Location) bool {
+	return strings.TrimRight(l.Addr, "/") == strings.TrimRight(other.Addr, "/") &&
+		strings.Trim(l.Namespace, "/") == strings.Trim(other.Namespace, "/") &&
+		strings.Trim(l.Mount, "/") == strings.Trim(other.Mount, "/")
+}
+
+// Location returns the location c points at, with BasePath as the path.
+func (c *Config) Location() Location {
+	return Location{
+		Addr:      c.VaultAddr,
+		Token:     c.VaultToken,
+		Namespace: c.VaultNamespace,
+		Mount:     c.KVMount,
+		Path:      c.BasePath,
+	}
+}
+
+// ForLocation returns a copy of c that points at loc.
+func (c *Config) ForLocation(loc Location) *Config {
+	clone := c.Clone()
+	clone.VaultAddr = loc.Addr
+	clone.VaultToken = loc.Token
+	clone.VaultNamespace = loc.Namespace
+	clone.KVMount = loc.Mount
+	clone.BasePath = loc.Path
+	return clone
+}
+
+// Clone returns a copy of c that can be changed independently, e.g. to
+// talk to a second mount, namespace or cluster.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.AgeRecipients = append([]string(nil), c.AgeRecipients...)
+	clone.PGPFingerprints = append([]string(nil), c.PGPFingerprints...)
+	clone.ExcludeDirs = append([]string(nil), c.ExcludeDirs...)
+	return &clone
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
\ No newline at end of file
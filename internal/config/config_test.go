@@ -0,0 +1,45 @@
+package config
+
+import "testing"
+
+func TestParseLocation(t *testing.T) {
+	defaults := Location{Addr: "http://127.0.0.1:8200", Token: "t", Namespace: "root-ns"}
+
+	tests := []struct {
+		spec    string
+		want    Location
+		wantErr bool
+	}{
+		{spec: "kv/app/api", want: Location{Namespace: "root-ns", Mount: "kv", Path: "app/api"}},
+		{spec: "team-a:kv/app/api", want: Location{Namespace: "team-a", Mount: "kv", Path: "app/api"}},
+		{spec: "team-a:kv", want: Location{Namespace: "team-a", Mount: "kv"}},
+		{spec: "/kv/app/", want: Location{Namespace: "root-ns", Mount: "kv", Path: "app"}},
+		// A ":" after the first "/" belongs to the path
+		{spec: "kv/app:v2", want: Location{Namespace: "root-ns", Mount: "kv", Path: "app:v2"}},
+		{spec: "team-a:kv/app:v2", want: Location{Namespace: "team-a", Mount: "kv", Path: "app:v2"}},
+		// A leading ":" keeps the default namespace for a mount with a ":"
+		{spec: ":kv:v2/app", want: Location{Namespace: "root-ns", Mount: "kv:v2", Path: "app"}},
+		{spec: "", wantErr: true},
+		{spec: "team-a:", wantErr: true},
+		{spec: "team-a:/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseLocation(tt.spec, defaults)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseLocation(%q) = %+v, want an error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLocation(%q) error = %v", tt.spec, err)
+			}
+			tt.want.Addr, tt.want.Token = defaults.Addr, defaults.Token
+			if got != tt.want {
+				t.Errorf("ParseLocation(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
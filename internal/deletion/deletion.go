@@ -0,0 +1,312 @@
+package deletion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/vault"
+)
+
+type Action string
+
+const (
+	// ActionDelete soft-deletes versions; they can be undeleted later.
+	ActionDelete Action = "delete"
+	// ActionUndelete restores soft-deleted versions.
+	ActionUndelete Action = "undelete"
+	// ActionDestroy permanently removes the data of versions.
+	ActionDestroy Action = "destroy"
+	// ActionDeleteMetadata permanently removes a secret with all versions.
+	ActionDeleteMetadata Action = "delete-metadata"
+)
+
+// Options selects what a Deleter changes. Without Versions, the current
+// version of each secret is used.
+type Options struct {
+	Action    Action
+	Versions  []int64
+	Recursive bool
+}
+
+// change is the planned change to a single secret.
+type change struct {
+	path     string
+	versions []int64
+}
+
+type Deleter struct {
+	client *vault.Client
+	config *config.Config
+}
+
+func New(client *vault.Client, cfg *config.Config) *Deleter {
+	return &Deleter{
+		client: client,
+		config: cfg,
+	}
+}
+
+// Run plans the changes for secretPath (or every secret below it with
+// opts.Recursive), shows them, asks for a single confirmation and applies
+// them.
+func (d *Deleter) Run(ctx context.Context, secretPath string, opts Options) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Starting deletion operation",
+		"action", string(opts.Action),
+		"path", secretPath,
+		"versions", opts.Versions,
+		"recursive", opts.Recursive,
+		"dry_run", d.config.DryRun)
+
+	var changes []change
+	if opts.Recursive {
+		err := d.client.WalkSecrets(ctx, secretPath, func(path string) error {
+			c, err := d.plan(ctx, path, opts)
+			if err != nil {
+				return err
+			}
+			if c != nil {
+				changes = append(changes, *c)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		c, err := d.plan(ctx, secretPath, opts)
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	if len(changes) == 0 {
+		fmt.Printf("Nothing to %s\n", d.verb(opts))
+		return nil
+	}
+
+	kvV1 := d.deletesKVv1(opts)
+	versionCount := 0
+	fmt.Printf("\nWill %s:\n", d.verb(opts))
+	for _, c := range changes {
+		versionCount += len(c.versions)
+		switch {
+		case kvV1:
+			fmt.Printf("  %s\n", c.path)
+		case opts.Action == ActionDeleteMetadata:
+			fmt.Printf("  %s (metadata and %d versions)\n", c.path, len(c.versions))
+		default:
+			fmt.Printf("  %s (%s)\n", c.path, describeVersions(c.versions))
+		}
+	}
+	summary := fmt.Sprintf("%d versions of %d secrets", versionCount, len(changes))
+	switch {
+	case kvV1:
+		summary = fmt.Sprintf("%d secrets", len(changes))
+	case opts.Action == ActionDeleteMetadata:
+		summary = fmt.Sprintf("%d secrets with all their versions", len(changes))
+	}
+
+	if d.config.DryRun {
+		logger.InfoCtx(ctx, "Dry run mode - would change secrets",
+			"action", string(opts.Action),
+			"secrets", len(changes),
+			"versions", versionCount)
+		fmt.Printf("\n[DRY RUN] Would %s %s\n", d.verb(opts), summary)
+		return nil
+	}
+
+	if !d.config.AutoApprove {
+		question := fmt.Sprintf("\n%s %s?", capitalize(d.verb(opts)), summary)
+		if opts.Action == ActionDestroy || opts.Action == ActionDeleteMetadata || kvV1 {
+			question = fmt.Sprintf("\n%s %s? This cannot be undone.", capitalize(d.verb(opts)), summary)
+		}
+		if !prompt.Confirm(ctx, question) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.InfoCtx(ctx, "User cancelled deletion operation", "action", string(opts.Action))
+			fmt.Println("✗ Cancelled, nothing was changed")
+			return nil
+		}
+	}
+
+	applied := 0
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			fmt.Printf("\nInterrupted: %d of %d secrets changed\n", applied, len(changes))
+			return err
+		}
+		// A started request is allowed to complete so the summary is accurate.
+		if err := d.apply(context.WithoutCancel(ctx), opts, c); err != nil {
+			fmt.Printf("✗ Failed to %s %s\n", verb(opts.Action), c.path)
+			return errors.WrapWithPath(err, string(opts.Action), c.path)
+		}
+		applied++
+		switch {
+		case kvV1:
+			fmt.Printf("✓ %s %s\n", pastTense(opts.Action), c.path)
+		case opts.Action == ActionDeleteMetadata:
+			fmt.Printf("✓ %s %s (metadata and all versions)\n", pastTense(opts.Action), c.path)
+		default:
+			fmt.Printf("✓ %s %s (%s)\n", pastTense(opts.Action), c.path, describeVersions(c.versions))
+		}
+	}
+
+	logger.InfoCtx(ctx, "Deletion operation completed",
+		"action", string(opts.Action),
+		"secrets", applied,
+		"versions", versionCount,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	fmt.Printf("\n%s %s\n", pastTense(opts.Action), summary)
+	return nil
+}
+
+// plan resolves the versions of secretPath that opts.Action applies to. It
+// returns nil when there is nothing to change for the secret.
+func (d *Deleter) plan(ctx context.Context, secretPath string, opts Options) (*change, error) {
+	if d.deletesKVv1(opts) {
+		if _, err := d.client.ReadSecret(ctx, secretPath); err != nil {
+			return nil, errors.WrapWithPath(err, "read_secret", secretPath)
+		}
+		return &change{path: secretPath}, nil
+	}
+
+	metadata, err := d.client.ReadMetadata(ctx, secretPath)
+	if err != nil {
+		return nil, errors.WrapWithPath(err, "read_metadata", secretPath)
+	}
+
+	if opts.Action == ActionDeleteMetadata {
+		versions := make([]int64, 0, len(metadata.Versions))
+		for _, v := range metadata.Versions {
+			versions = append(versions, v.Version)
+		}
+		return &change{path: secretPath, versions: versions}, nil
+	}
+
+	requested := opts.Versions
+	if len(requested) == 0 {
+		requested = []int64{metadata.CurrentVersion}
+	}
+
+	var versions []int64
+	for _, version := range requested {
+		info, ok := metadata.Version(version)
+		if !ok {
+			return nil, errors.NewWithPath("resolve_versions", secretPath,
+				fmt.Errorf("version %d does not exist or is no longer kept", version)).
+				WithContext("current_version", metadata.CurrentVersion).
+				WithContext("action", string(opts.Action))
+		}
+		if reason := skipReason(opts.Action, info); reason != "" {
+			logger.DebugCtx(ctx, "Skipping version", "path", secretPath, "version", version, "reason", reason)
+			fmt.Printf("- %s version %d: %s\n", secretPath, version, reason)
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	return &change{path: secretPath, versions: versions}, nil
+}
+
+// deletesKVv1 reports whether opts deletes secrets of a KV v1 mount. KV v1
+// has no versions, so a delete without --versions removes the secret for
+// good; every other action needs KV v2.
+func (d *Deleter) deletesKVv1(opts Options) bool {
+	return d.client.KVVersion() == 1 && opts.Action == ActionDelete && len(opts.Versions) == 0
+}
+
+func (d *Deleter) apply(ctx context.Context, opts Options, c change) error {
+	if d.deletesKVv1(opts) {
+		return d.client.DeleteSecret(ctx, c.path)
+	}
+
+	switch opts.Action {
+	case ActionDelete:
+		return d.client.DeleteVersions(ctx, c.path, c.versions)
+	case ActionUndelete:
+		return d.client.UndeleteVersions(ctx, c.path, c.versions)
+	case ActionDestroy:
+		return d.client.DestroyVersions(ctx, c.path, c.versions)
+	case ActionDeleteMetadata:
+		return d.client.DeleteMetadata(ctx, c.path)
+	default:
+		return fmt.Errorf("unknown action %q", opts.Action)
+	}
+}
+
+// skipReason returns why action does not apply to a version, if it does not.
+func skipReason(action Action, info vault.VersionInfo) string {
+	switch {
+	case info.Destroyed:
+		return "already destroyed"
+	case action == ActionDelete && info.Deleted():
+		return "already deleted"
+	case action == ActionUndelete && !info.Deleted():
+		return "not deleted"
+	}
+	return ""
+}
+
+func describeVersions(versions []int64) string {
+	parts := make([]string, len(versions))
+	for i, v := range versions {
+		parts[i] = fmt.Sprintf("%d", v)
+	}
+	if len(versions) == 1 {
+		return "version " + parts[0]
+	}
+	return "versions " + strings.Join(parts, ", ")
+}
+
+func (d *Deleter) verb(opts Options) string {
+	if d.deletesKVv1(opts) {
+		return "permanently delete"
+	}
+	return verb(opts.Action)
+}
+
+func verb(action Action) string {
+	switch action {
+	case ActionDelete:
+		return "soft-delete"
+	case ActionDeleteMetadata:
+		return "permanently delete"
+	default:
+		return string(action)
+	}
+}
+
+func pastTense(action Action) string {
+	switch action {
+	case ActionDelete, ActionDeleteMetadata:
+		return "Deleted"
+	case ActionUndelete:
+		return "Undeleted"
+	case ActionDestroy:
+		return "Destroyed"
+	default:
+		return string(action)
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
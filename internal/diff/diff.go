@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
@@ -15,6 +16,23 @@ type SecretDiff struct {
 	Proposed *vault.Secret
 	HasDiff  bool
 	DiffText string
+	Changes  []KeyChange
+}
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeModified ChangeType = "modified"
+	ChangeRemoved  ChangeType = "removed"
+)
+
+// KeyChange is a change to a single key of a secret.
+type KeyChange struct {
+	Key      string
+	Type     ChangeType
+	OldValue string
+	NewValue string
 }
 
 func CompareSecrets(current, proposed *vault.Secret) (*SecretDiff, error) {
@@ -44,15 +62,81 @@ func CompareSecrets(current, proposed *vault.Secret) (*SecretDiff, error) {
 		diffText = formatUnifiedDiff(current.Path, currentYAML, proposedYAML)
 	}
 
+	var currentData, proposedData map[string]string
+	if current != nil {
+		currentData = current.Data
+	}
+	if proposed != nil {
+		proposedData = proposed.Data
+	}
+
 	return &SecretDiff{
 		Path:     current.Path,
 		Current:  current,
 		Proposed: proposed,
 		HasDiff:  hasDiff,
 		DiffText: diffText,
+		Changes:  CompareKeys(currentData, proposedData),
 	}, nil
 }
 
+// CompareKeys returns the key-level changes from current to proposed,
+// sorted by key.
+func CompareKeys(current, proposed map[string]string) []KeyChange {
+	var changes []KeyChange
+
+	for key, newValue := range proposed {
+		oldValue, exists := current[key]
+		switch {
+		case !exists:
+			changes = append(changes, KeyChange{Key: key, Type: ChangeAdded, NewValue: newValue})
+		case oldValue != newValue:
+			changes = append(changes, KeyChange{Key: key, Type: ChangeModified, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	for key, oldValue := range current {
+		if _, exists := proposed[key]; !exists {
+			changes = append(changes, KeyChange{Key: key, Type: ChangeRemoved, OldValue: oldValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// Patch returns the patch that applies changes to a secret.
+func Patch(changes []KeyChange) vault.SecretPatch {
+	patch := vault.SecretPatch{Set: make(map[string]string)}
+	for _, change := range changes {
+		if change.Type == ChangeRemoved {
+			patch.Remove = append(patch.Remove, change.Key)
+		} else {
+			patch.Set[change.Key] = change.NewValue
+		}
+	}
+	return patch
+}
+
+// Apply returns a copy of data with changes applied.
+func Apply(data map[string]string, changes []KeyChange) map[string]string {
+	result := make(map[string]string, len(data))
+	for k, v := range data {
+		result[k] = v
+	}
+	for _, change := range changes {
+		if change.Type == ChangeRemoved {
+			delete(result, change.Key)
+		} else {
+			result[change.Key] = change.NewValue
+		}
+	}
+	return result
+}
+
 func secretToYAML(secret *vault.Secret) (string, error) {
 	if secret == nil {
 		return "", nil
@@ -111,4 +195,39 @@ func PrintDiff(diff *SecretDiff) {
 
 	fmt.Printf("Changes for secret: %s\n", diff.Path)
 	fmt.Printf("%s\n", diff.DiffText)
-}
\ No newline at end of file
+}
+
+// PrintKeyChanges prints key-level changes, one line per key.
+func PrintKeyChanges(changes []KeyChange) {
+	if len(changes) == 0 {
+		fmt.Println("  (no changes)")
+		return
+	}
+
+	for _, change := range changes {
+		switch change.Type {
+		case ChangeAdded:
+			fmt.Printf("  + %s: %s\n", change.Key, change.NewValue)
+		case ChangeModified:
+			fmt.Printf("  ~ %s: %s -> %s\n", change.Key, change.OldValue, change.NewValue)
+		case ChangeRemoved:
+			fmt.Printf("  - %s: %s\n", change.Key, change.OldValue)
+		}
+	}
+}
+
+// MaskValues replaces the values of changes with a placeholder so a report
+// can show which keys differ without revealing secret values.
+func MaskValues(changes []KeyChange) []KeyChange {
+	masked := make([]KeyChange, len(changes))
+	for i, change := range changes {
+		masked[i] = change
+		if change.OldValue != "" {
+			masked[i].OldValue = "***"
+		}
+		if change.NewValue != "" {
+			masked[i].NewValue = "***"
+		}
+	}
+	return masked
+}
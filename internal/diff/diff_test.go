@@ -0,0 +1,130 @@
+package diff
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestCompareKeys(t *testing.T) {
+	current := map[string]string{"user": "app", "password": "old", "legacy": "x"}
+	proposed := map[string]string{"user": "app", "password": "new", "host": "db"}
+
+	want := []KeyChange{
+		{Key: "host", Type: ChangeAdded, NewValue: "db"},
+		{Key: "legacy", Type: ChangeRemoved, OldValue: "x"},
+		{Key: "password", Type: ChangeModified, OldValue: "old", NewValue: "new"},
+	}
+	if got := CompareKeys(current, proposed); !reflect.DeepEqual(got, want) {
+		t.Errorf("CompareKeys() = %+v, want %+v", got, want)
+	}
+	if got := CompareKeys(current, current); len(got) != 0 {
+		t.Errorf("CompareKeys() of equal data = %+v, want no changes", got)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		changes []KeyChange
+		want    map[string]string
+	}{
+		{
+			name: "no changes",
+			data: map[string]string{"a": "1"},
+			want: map[string]string{"a": "1"},
+		},
+		{
+			name:    "add to empty secret",
+			data:    nil,
+			changes: []KeyChange{{Key: "a", Type: ChangeAdded, NewValue: "1"}},
+			want:    map[string]string{"a": "1"},
+		},
+		{
+			name: "add, modify and remove",
+			data: map[string]string{"a": "1", "b": "2", "c": "3"},
+			changes: []KeyChange{
+				{Key: "a", Type: ChangeModified, OldValue: "1", NewValue: "10"},
+				{Key: "b", Type: ChangeRemoved, OldValue: "2"},
+				{Key: "d", Type: ChangeAdded, NewValue: "4"},
+			},
+			want: map[string]string{"a": "10", "c": "3", "d": "4"},
+		},
+		{
+			// Keys changed in Vault after the diff are kept, as with a PATCH
+			name: "keys outside the changes are kept",
+			data: map[string]string{"a": "1", "z": "changed meanwhile"},
+			changes: []KeyChange{
+				{Key: "a", Type: ChangeModified, OldValue: "1", NewValue: "2"},
+			},
+			want: map[string]string{"a": "2", "z": "changed meanwhile"},
+		},
+		{
+			name:    "removing a missing key",
+			data:    map[string]string{"a": "1"},
+			changes: []KeyChange{{Key: "b", Type: ChangeRemoved, OldValue: "2"}},
+			want:    map[string]string{"a": "1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before map[string]string
+			if tt.data != nil {
+				before = make(map[string]string, len(tt.data))
+				for k, v := range tt.data {
+					before[k] = v
+				}
+			}
+
+			if got := Apply(tt.data, tt.changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.data, before) {
+				t.Errorf("Apply() modified its input: %v, was %v", tt.data, before)
+			}
+		})
+	}
+}
+
+func TestApplyCompareKeysRoundTrip(t *testing.T) {
+	current := map[string]string{"a": "1", "b": "2", "c": "3"}
+	proposed := map[string]string{"a": "1", "b": "20", "d": "4"}
+
+	if got := Apply(current, CompareKeys(current, proposed)); !reflect.DeepEqual(got, proposed) {
+		t.Errorf("Apply(CompareKeys()) = %v, want %v", got, proposed)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	changes := []KeyChange{
+		{Key: "a", Type: ChangeModified, OldValue: "1", NewValue: "10"},
+		{Key: "b", Type: ChangeRemoved, OldValue: "2"},
+		{Key: "c", Type: ChangeAdded, NewValue: "3"},
+	}
+
+	patch := Patch(changes)
+	if want := map[string]string{"a": "10", "c": "3"}; !reflect.DeepEqual(patch.Set, want) {
+		t.Errorf("Patch().Set = %v, want %v", patch.Set, want)
+	}
+	sort.Strings(patch.Remove)
+	if want := []string{"b"}; !reflect.DeepEqual(patch.Remove, want) {
+		t.Errorf("Patch().Remove = %v, want %v", patch.Remove, want)
+	}
+	if !Patch(nil).Empty() {
+		t.Error("Patch(nil) is not empty")
+	}
+}
+
+func TestMaskValues(t *testing.T) {
+	changes := []KeyChange{{Key: "a", Type: ChangeModified, OldValue: "secret", NewValue: "other"}}
+
+	masked := MaskValues(changes)
+	if masked[0].OldValue == "secret" || masked[0].NewValue == "other" {
+		t.Errorf("MaskValues() = %+v, still shows the values", masked[0])
+	}
+	if changes[0].OldValue != "secret" {
+		t.Errorf("MaskValues() modified its input: %+v", changes[0])
+	}
+}
@@ -0,0 +1,233 @@
+package edit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"vault-sync/internal/codec"
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/vault"
+)
+
+type Editor struct {
+	client *vault.Client
+	config *config.Config
+	codec  codec.Codec
+}
+
+func New(client *vault.Client, cfg *config.Config) *Editor {
+	return &Editor{
+		client: client,
+		config: cfg,
+		// The temp file is always plaintext YAML, whatever the encryption
+		// of the local tree, so it can be edited directly.
+		codec: codec.NewYAML(),
+	}
+}
+
+// Edit opens the latest version of a secret in the user's editor and writes
+// the edited data back as a new version once approved. The write uses
+// check-and-set on the version that was opened; if the secret changed in
+// the meantime, the edited keys can be applied to the latest version and
+// reviewed again.
+func (e *Editor) Edit(ctx context.Context, secretPath string) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Starting edit operation", "path", secretPath)
+
+	current, err := e.client.ReadSecret(ctx, secretPath)
+	if err != nil {
+		if vault.IsNotFound(err) {
+			return errors.NewWithPath("read_secret", secretPath, fmt.Errorf("secret does not exist")).
+				WithContext("mount", e.config.KVMount).
+				WithContext("hint", "edit only changes existing secrets")
+		}
+		return errors.WrapWithPath(err, "read_secret", secretPath)
+	}
+
+	// Created by os.CreateTemp with mode 0600, so only the owner can read
+	// the plaintext values
+	file, err := os.CreateTemp("", "vault-sync-edit-*.yaml")
+	if err != nil {
+		return errors.New("create_temp_file", err)
+	}
+	tempPath := file.Name()
+	file.Close()
+	defer func() {
+		if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+			logger.WarnCtx(ctx, "Failed to remove temp file", "temp_path", tempPath, "error", err)
+		}
+	}()
+
+	body, err := e.codec.Encode(ctx, current.Data)
+	if err != nil {
+		return errors.WrapWithPath(err, "encode_secret", secretPath)
+	}
+
+	for {
+		header := e.header(current)
+		if err := os.WriteFile(tempPath, append(header, body...), 0600); err != nil {
+			return errors.New("write_temp_file", err).WithContext("temp_path", tempPath)
+		}
+
+		if err := e.runEditor(ctx, tempPath); err != nil {
+			return err
+		}
+		// An interrupt while the editor was open abandons the edit
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(tempPath)
+		if err != nil {
+			return errors.New("read_temp_file", err).WithContext("temp_path", tempPath)
+		}
+		body = bytes.TrimPrefix(edited, header)
+
+		data, err := e.codec.Decode(ctx, edited)
+		if err != nil {
+			fmt.Printf("✗ Cannot parse the edited secret: %v\n", err)
+			if prompt.Confirm(ctx, "Edit again?") {
+				continue
+			}
+			return errors.NewWithPath("parse_edited_secret", secretPath, err)
+		}
+		if data == nil {
+			logger.InfoCtx(ctx, "Edited file is empty, aborting edit", "path", secretPath)
+			fmt.Println("✗ The file is empty, nothing was written")
+			return nil
+		}
+
+		changes := diff.CompareKeys(current.Data, data)
+		if len(changes) == 0 {
+			logger.InfoCtx(ctx, "Secret was not changed in editor", "path", secretPath)
+			fmt.Printf("✓ No changes to %s\n", secretPath)
+			return nil
+		}
+
+		fmt.Printf("Changes to %s:\n", secretPath)
+		diff.PrintKeyChanges(changes)
+
+		if !e.config.AutoApprove {
+			if !prompt.Confirm(ctx, fmt.Sprintf("Write changes to %s?", secretPath)) {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logger.InfoCtx(ctx, "User discarded edit", "path", secretPath)
+				fmt.Printf("✗ Discarded changes to %s\n", secretPath)
+				return nil
+			}
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		proposed := &vault.Secret{Path: secretPath, Data: data}
+		err = e.write(ctx, proposed, current.Version)
+		if err == nil {
+			logger.InfoCtx(ctx, "Edited secret",
+				"path", secretPath,
+				"opened_version", current.Version,
+				"new_version", proposed.Version,
+				"changes", len(changes),
+				"duration_ms", time.Since(start).Milliseconds())
+			if proposed.Version > 0 {
+				fmt.Printf("✓ Updated %s (now version %d)\n", secretPath, proposed.Version)
+			} else {
+				fmt.Printf("✓ Updated %s\n", secretPath)
+			}
+			return nil
+		}
+		if !vault.IsCASMismatch(err) {
+			return errors.WrapWithPath(err, "write_secret", secretPath)
+		}
+
+		latest, readErr := e.client.ReadSecret(ctx, secretPath)
+		if readErr != nil {
+			return errors.WrapWithPath(readErr, "read_secret", secretPath)
+		}
+		logger.WarnCtx(ctx, "Secret changed while editing",
+			"path", secretPath,
+			"opened_version", current.Version,
+			"latest_version", latest.Version)
+		fmt.Printf("✗ %s was changed in Vault while you were editing (opened version %d, now version %d). Keys changed meanwhile:\n",
+			secretPath, current.Version, latest.Version)
+		diff.PrintKeyChanges(diff.MaskValues(diff.CompareKeys(current.Data, latest.Data)))
+		if !prompt.Confirm(ctx, "Edit again with your changes applied to the latest version?") {
+			return errors.WrapWithPath(err, "write_secret", secretPath)
+		}
+		current = latest
+		body, err = e.codec.Encode(ctx, diff.Apply(latest.Data, changes))
+		if err != nil {
+			return errors.WrapWithPath(err, "encode_secret", secretPath)
+		}
+	}
+}
+
+// write stores proposed with check-and-set on version. KV v1 has no
+// check-and-set, so there the data is written as is.
+func (e *Editor) write(ctx context.Context, proposed *vault.Secret, version int64) error {
+	// Once approved, the write is allowed to complete even if an interrupt
+	// arrives meanwhile.
+	writeCtx := context.WithoutCancel(ctx)
+	if e.client.KVVersion() == 1 {
+		fmt.Println("Note: KV version 1 has no check-and-set, changes made meanwhile are overwritten")
+		return e.client.WriteSecret(writeCtx, proposed)
+	}
+	return e.client.WriteSecretCAS(writeCtx, proposed, version)
+}
+
+// header is the comment block at the top of the temp file. YAML ignores it,
+// and it is stripped again when the file is reopened to fix a parse error.
+func (e *Editor) header(secret *vault.Secret) []byte {
+	var b strings.Builder
+	location := e.config.KVMount + "/" + secret.Path
+	if e.config.VaultNamespace != "" {
+		location = e.config.VaultNamespace + "/" + location
+	}
+	if secret.Version > 0 {
+		fmt.Fprintf(&b, "# Editing %s (version %d)\n", location, secret.Version)
+	} else {
+		fmt.Fprintf(&b, "# Editing %s\n", location)
+	}
+	b.WriteString("# Save and quit to review the changes before they are written.\n")
+	b.WriteString("# Leave the file empty to abort.\n")
+	return []byte(b.String())
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to vi, and waits
+// for it to exit. The editor is not tied to ctx, so an interrupt meant for
+// the editor does not kill it; the caller checks ctx afterwards.
+func (e *Editor) runEditor(ctx context.Context, path string) error {
+	args := editorCommand()
+	logger.DebugCtx(ctx, "Opening editor", "editor", args[0])
+
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return errors.New("run_editor", err).
+			WithContext("editor", strings.Join(args, " ")).
+			WithContext("hint", "set $EDITOR to the editor to use")
+	}
+	return nil
+}
+
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if args := strings.Fields(os.Getenv(name)); len(args) > 0 {
+			return args
+		}
+	}
+	return []string{"vi"}
+}
@@ -0,0 +1,125 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes data to a temporary file in the same directory and
+// renames it over path, so readers never observe a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	cleanup := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := tmp.Chmod(perm); err != nil {
+		return cleanup(err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return cleanup(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return cleanup(err)
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	return SyncDir(dir)
+}
+
+// SyncDir flushes directory entries so that a preceding rename survives a crash.
+func SyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Some platforms and filesystems do not support syncing directories, so
+	// a failed sync is not treated as an error.
+	d.Sync()
+	return nil
+}
+
+// SwapDir atomically replaces target with the contents of replacement. The
+// previous target is moved aside first and restored if the swap fails.
+func SwapDir(replacement, target string) error {
+	if err := RecoverSwap(target); err != nil {
+		return err
+	}
+	backup := target + ".old"
+
+	hadTarget := true
+	if err := os.Rename(target, backup); err != nil {
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("move %s aside: %w", target, err)
+		}
+		hadTarget = false
+	}
+
+	if err := os.Rename(replacement, target); err != nil {
+		if hadTarget {
+			if restoreErr := os.Rename(backup, target); restoreErr != nil {
+				return fmt.Errorf("swap %s: %w (restore failed, previous tree is at %s: %v)", target, err, backup, restoreErr)
+			}
+		}
+		return fmt.Errorf("swap %s: %w", target, err)
+	}
+
+	if err := SyncDir(filepath.Dir(target)); err != nil {
+		return err
+	}
+
+	if hadTarget {
+		return os.RemoveAll(backup)
+	}
+	return nil
+}
+
+// RecoverSwap deals with the backup left by a SwapDir of target that was
+// interrupted. If target is missing, the crash happened between moving it
+// aside and moving the replacement in, and the backup is the only copy of the
+// previous tree, so it is moved back. Otherwise the swap completed and the
+// backup is stale.
+func RecoverSwap(target string) error {
+	backup := target + ".old"
+	if _, err := os.Lstat(backup); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("check backup %s: %w", backup, err)
+	}
+
+	_, err := os.Lstat(target)
+	switch {
+	case os.IsNotExist(err):
+		if err := os.Rename(backup, target); err != nil {
+			return fmt.Errorf("restore previous tree from %s: %w (move it back to %s by hand)", backup, err, target)
+		}
+		return SyncDir(filepath.Dir(target))
+	case err != nil:
+		return fmt.Errorf("check %s: %w", target, err)
+	}
+
+	if err := os.RemoveAll(backup); err != nil {
+		return fmt.Errorf("remove stale backup %s: %w", backup, err)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files below dir, keyed by slash-separated relative path.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the files below dir, or nil if dir does not exist.
+func readTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func assertTree(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	got := readTree(t, dir)
+	if len(got) != len(want) || (got == nil) != (want == nil) {
+		t.Fatalf("tree %s = %v, want %v", dir, got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("%s/%s = %q, want %q", dir, name, got[name], content)
+		}
+	}
+}
+
+func TestSwapDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      map[string]string
+		backup      map[string]string
+		replacement map[string]string
+	}{
+		{
+			name:        "replace existing tree",
+			target:      map[string]string{"app/db.yaml": "old", "gone.yaml": "old"},
+			replacement: map[string]string{"app/db.yaml": "new"},
+		},
+		{
+			name:        "no previous tree",
+			replacement: map[string]string{"app/db.yaml": "new"},
+		},
+		{
+			name:        "stale backup of a completed swap",
+			target:      map[string]string{"app/db.yaml": "old"},
+			backup:      map[string]string{"app/db.yaml": "older"},
+			replacement: map[string]string{"app/db.yaml": "new"},
+		},
+		{
+			name:        "backup of an interrupted swap",
+			backup:      map[string]string{"app/db.yaml": "old"},
+			replacement: map[string]string{"app/db.yaml": "new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			target := filepath.Join(root, "secrets")
+			replacement := filepath.Join(root, "secrets.staging")
+
+			if tt.target != nil {
+				writeTree(t, target, tt.target)
+			}
+			if tt.backup != nil {
+				writeTree(t, target+".old", tt.backup)
+			}
+			writeTree(t, replacement, tt.replacement)
+
+			if err := SwapDir(replacement, target); err != nil {
+				t.Fatalf("SwapDir() error = %v", err)
+			}
+
+			assertTree(t, target, tt.replacement)
+			assertTree(t, replacement, nil)
+			assertTree(t, target+".old", nil)
+		})
+	}
+}
+
+func TestSwapDirMissingReplacementKeepsTarget(t *testing.T) {
+	root := t.TempDir()
+	target := filepath.Join(root, "secrets")
+	writeTree(t, target, map[string]string{"app/db.yaml": "old"})
+
+	if err := SwapDir(filepath.Join(root, "missing"), target); err == nil {
+		t.Fatal("SwapDir() with a missing replacement succeeded")
+	}
+
+	assertTree(t, target, map[string]string{"app/db.yaml": "old"})
+	assertTree(t, target+".old", nil)
+}
+
+func TestRecoverSwap(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     map[string]string
+		backup     map[string]string
+		wantTarget map[string]string
+	}{
+		{
+			name:       "nothing to recover",
+			target:     map[string]string{"db.yaml": "current"},
+			wantTarget: map[string]string{"db.yaml": "current"},
+		},
+		{
+			name: "neither target nor backup",
+		},
+		{
+			name:       "interrupted before the replacement was moved in",
+			backup:     map[string]string{"db.yaml": "previous"},
+			wantTarget: map[string]string{"db.yaml": "previous"},
+		},
+		{
+			name:       "interrupted before the backup was removed",
+			target:     map[string]string{"db.yaml": "current"},
+			backup:     map[string]string{"db.yaml": "previous"},
+			wantTarget: map[string]string{"db.yaml": "current"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := filepath.Join(t.TempDir(), "secrets")
+			if tt.target != nil {
+				writeTree(t, target, tt.target)
+			}
+			if tt.backup != nil {
+				writeTree(t, target+".old", tt.backup)
+			}
+
+			if err := RecoverSwap(target); err != nil {
+				t.Fatalf("RecoverSwap() error = %v", err)
+			}
+
+			assertTree(t, target, tt.wantTarget)
+			assertTree(t, target+".old", nil)
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yaml")
+
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(path, []byte(content), 0600); err != nil {
+			t.Fatalf("WriteFileAtomic() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != content {
+			t.Errorf("file = %q, want %q", data, content)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("file mode = %v, want 0600", perm)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want only the file (no temporary files left)", len(entries))
+	}
+}
@@ -0,0 +1,121 @@
+package get
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"vault-sync/internal/codec"
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/vault"
+)
+
+// Output formats of Get.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatRaw  = "raw"
+)
+
+type Getter struct {
+	client *vault.Client
+	config *config.Config
+	out    io.Writer
+}
+
+func New(client *vault.Client, cfg *config.Config) *Getter {
+	return &Getter{
+		client: client,
+		config: cfg,
+		out:    os.Stdout,
+	}
+}
+
+// Get prints a secret, or the value of one of its keys when key is set, to
+// stdout. A version of 0 reads the latest version. Without a format, a
+// whole secret is printed as YAML, as in pulled files, and a single value
+// raw, exactly as stored.
+func (g *Getter) Get(ctx context.Context, secretPath, key string, version int64, format string) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Starting get operation",
+		"path", secretPath,
+		"key", key,
+		"version", version,
+		"format", format)
+
+	switch {
+	case format == "" && key != "":
+		format = FormatRaw
+	case format == "":
+		format = FormatYAML
+	case format != FormatYAML && format != FormatJSON && format != FormatRaw:
+		return errors.New("parse_format", fmt.Errorf("unknown format %q, use yaml, json or raw", format))
+	case format == FormatRaw && key == "":
+		return errors.New("parse_format", fmt.Errorf("the raw format prints a single value, give a key"))
+	}
+
+	var secret *vault.Secret
+	var err error
+	if version > 0 {
+		secret, err = g.client.ReadSecretVersion(ctx, secretPath, version)
+	} else {
+		secret, err = g.client.ReadSecret(ctx, secretPath)
+	}
+	if err != nil {
+		return errors.WrapWithPath(err, "read_secret", secretPath)
+	}
+
+	var output []byte
+	if key != "" {
+		value, ok := secret.Data[key]
+		if !ok {
+			return errors.NewWithPath("get_key", secretPath, fmt.Errorf("key %q not found", key)).
+				WithContext("version", secret.Version)
+		}
+		output, err = g.encodeValue(value, format)
+	} else {
+		output, err = g.encodeSecret(ctx, secret.Data, format)
+	}
+	if err != nil {
+		return errors.NewWithPath("encode_output", secretPath, err).WithContext("format", format)
+	}
+
+	if _, err := g.out.Write(output); err != nil {
+		return errors.New("write_output", err)
+	}
+
+	logger.DebugCtx(ctx, "Printed secret",
+		"path", secretPath,
+		"version", secret.Version,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+func (g *Getter) encodeSecret(ctx context.Context, data map[string]string, format string) ([]byte, error) {
+	if format == FormatJSON {
+		output, err := json.MarshalIndent(data, "", "  ")
+		return append(output, '\n'), err
+	}
+	return codec.NewYAML().Encode(ctx, data)
+}
+
+func (g *Getter) encodeValue(value, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		output, err := json.Marshal(value)
+		return append(output, '\n'), err
+	case FormatYAML:
+		return yaml.Marshal(value)
+	default:
+		// No newline is added, so values such as certificates round-trip
+		// through put key=@file unchanged
+		return []byte(value), nil
+	}
+}
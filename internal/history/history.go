@@ -0,0 +1,129 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/vault"
+)
+
+type History struct {
+	client *vault.Client
+	config *config.Config
+}
+
+func New(client *vault.Client, cfg *config.Config) *History {
+	return &History{
+		client: client,
+		config: cfg,
+	}
+}
+
+// Show prints every version of a secret from its KV v2 metadata and, with
+// showDiff, the key-level changes between consecutive readable versions.
+func (h *History) Show(ctx context.Context, secretPath string, showDiff bool) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Showing secret history", "path", secretPath, "diff", showDiff)
+
+	metadata, err := h.client.ReadMetadata(ctx, secretPath)
+	if err != nil {
+		return errors.WrapWithPath(err, "read_history", secretPath)
+	}
+
+	fmt.Printf("History for %s (current version %d, %d versions kept)\n\n",
+		secretPath, metadata.CurrentVersion, len(metadata.Versions))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCREATED\tDELETED\tDESTROYED")
+	for i := len(metadata.Versions) - 1; i >= 0; i-- {
+		v := metadata.Versions[i]
+		marker := ""
+		if v.Version == metadata.CurrentVersion {
+			marker = " (current)"
+		}
+		fmt.Fprintf(w, "%d%s\t%s\t%s\t%s\n",
+			v.Version, marker,
+			formatTime(v.CreatedTime),
+			formatDeletion(v),
+			yesNo(v.Destroyed))
+	}
+	w.Flush()
+
+	if showDiff {
+		if err := h.printDiffs(ctx, secretPath, metadata); err != nil {
+			return err
+		}
+	}
+
+	logger.InfoCtx(ctx, "Secret history shown",
+		"path", secretPath,
+		"version_count", len(metadata.Versions),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+func (h *History) printDiffs(ctx context.Context, secretPath string, metadata *vault.SecretMetadata) error {
+	var previous *vault.Secret
+
+	for _, v := range metadata.Versions {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fmt.Printf("\nVersion %d (%s)\n", v.Version, formatTime(v.CreatedTime))
+
+		if !v.Readable() {
+			state := "deleted"
+			if v.Destroyed {
+				state = "destroyed"
+			}
+			fmt.Printf("  (%s, data not available)\n", state)
+			continue
+		}
+
+		secret, err := h.client.ReadSecretVersion(ctx, secretPath, v.Version)
+		if err != nil {
+			return errors.WrapWithPath(err, "read_history_version", secretPath)
+		}
+
+		var previousData map[string]string
+		if previous != nil {
+			previousData = previous.Data
+		}
+		diff.PrintKeyChanges(diff.CompareKeys(previousData, secret.Data))
+		previous = secret
+	}
+
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+// formatDeletion shows when a version was deleted, or when it is going to be
+// deleted by delete_version_after.
+func formatDeletion(v vault.VersionInfo) string {
+	if !v.DeletionTime.IsZero() && !v.Deleted() {
+		return formatTime(v.DeletionTime) + " (scheduled)"
+	}
+	return formatTime(v.DeletionTime)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,230 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	StatusApplied   = "applied"
+	StatusUnchanged = "unchanged"
+	StatusSkipped   = "skipped"
+)
+
+// Header identifies the run a journal belongs to. A journal is only resumed
+// when the header of the new run matches the recorded one.
+type Header struct {
+	Operation string    `json:"operation"`
+	VaultAddr string    `json:"vault_addr"`
+	Namespace string    `json:"namespace,omitempty"`
+	Mount     string    `json:"mount"`
+	BasePath  string    `json:"base_path,omitempty"`
+	OutputDir string    `json:"output_dir"`
+	AsOf      string    `json:"as_of,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Entry records a path that was completed during a run. No secret material
+// is stored: an entry is verified on resume by comparing the Vault version
+// and, for push, the size and modification time of the local file.
+type Entry struct {
+	Path        string    `json:"path"`
+	Status      string    `json:"status"`
+	Version     int64     `json:"version"`
+	LocalFile   string    `json:"local_file,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// Journal is an append-only log of completed paths, one JSON object per
+// line after the header, synced after every entry so it survives crashes.
+type Journal struct {
+	path    string
+	file    *os.File
+	header  Header
+	entries map[string]Entry
+	// size is the length of the intact lines of a loaded journal.
+	size int64
+}
+
+// Open starts a journal at path. With resume set, an existing journal for
+// the same run is loaded and appended to; otherwise any previous journal is
+// replaced. The returned bool reports whether a journal was resumed.
+func Open(path string, header Header, resume bool) (*Journal, bool, error) {
+	j := &Journal{
+		path:    path,
+		header:  header,
+		entries: make(map[string]Entry),
+	}
+
+	if resume {
+		resumed, err := j.load()
+		if err != nil {
+			return nil, false, err
+		}
+		if resumed {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+			if err != nil {
+				return nil, false, err
+			}
+			// A torn last line is cut off, so the next entry starts a line
+			// of its own instead of being merged with the fragment.
+			if err := f.Truncate(j.size); err != nil {
+				f.Close()
+				return nil, false, err
+			}
+			j.file = f
+			return j, true, nil
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+	j.file = f
+
+	if err := j.append(header); err != nil {
+		f.Close()
+		return nil, false, err
+	}
+	return j, false, nil
+}
+
+func (j *Journal) load() (bool, error) {
+	f, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	// Without a complete header line there is nothing to resume
+	line, err := r.ReadBytes('\n')
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var recorded Header
+	if err := json.Unmarshal(line, &recorded); err != nil {
+		return false, fmt.Errorf("parse journal header: %w", err)
+	}
+	if !recorded.sameTarget(j.header) {
+		return false, fmt.Errorf("journal %s was recorded for %s of %s/%s into %s; rerun without --resume to start over",
+			j.path, recorded.Operation, recorded.Mount, recorded.BasePath, recorded.OutputDir)
+	}
+	j.header = recorded
+	j.size = int64(len(line))
+
+	for {
+		line, err := r.ReadBytes('\n')
+		// A crash can leave a torn last line, without its newline or with
+		// an incomplete entry; everything before it is intact.
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			break
+		}
+		j.entries[entry.Path] = entry
+		j.size += int64(len(line))
+	}
+
+	return true, nil
+}
+
+func (h Header) sameTarget(other Header) bool {
+	return h.Operation == other.Operation &&
+		h.VaultAddr == other.VaultAddr &&
+		h.Namespace == other.Namespace &&
+		h.Mount == other.Mount &&
+		h.BasePath == other.BasePath &&
+		h.OutputDir == other.OutputDir &&
+		h.AsOf == other.AsOf
+}
+
+// Lookup returns the recorded entry for path, if any. A nil journal, as
+// used for dry runs, never has entries and records nothing.
+func (j *Journal) Lookup(path string) (Entry, bool) {
+	if j == nil {
+		return Entry{}, false
+	}
+	entry, ok := j.entries[path]
+	return entry, ok
+}
+
+// Len returns the number of recorded entries.
+func (j *Journal) Len() int {
+	if j == nil {
+		return 0
+	}
+	return len(j.entries)
+}
+
+// Record appends an entry and syncs it to disk.
+func (j *Journal) Record(entry Entry) error {
+	if j == nil {
+		return nil
+	}
+	entry.CompletedAt = time.Now().UTC()
+	if err := j.append(entry); err != nil {
+		return err
+	}
+	j.entries[entry.Path] = entry
+	return nil
+}
+
+func (j *Journal) append(v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Close closes the journal file, keeping it for a later resume.
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+// Remove closes and deletes the journal once a run has completed.
+func (j *Journal) Remove() error {
+	if j == nil {
+		return nil
+	}
+	j.file.Close()
+	if err := os.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// FileFingerprint describes the state of a local file without reading it,
+// so a resumed push can tell whether the file was edited in between.
+func FileFingerprint(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", info.Size(), info.ModTime().UnixNano())
+}
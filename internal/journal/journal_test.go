@@ -0,0 +1,221 @@
+package journal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testHeader() Header {
+	return Header{
+		Operation: "push",
+		VaultAddr: "http://127.0.0.1:8200",
+		Mount:     "secret",
+		BasePath:  "app",
+		OutputDir: "./secrets",
+	}
+}
+
+func jsonLine(t *testing.T, v interface{}) string {
+	t.Helper()
+	line, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(line) + "\n"
+}
+
+func TestOpenResume(t *testing.T) {
+	header := testHeader()
+	otherMount := testHeader()
+	otherMount.Mount = "kv"
+
+	first := Entry{Path: "app/one", Status: StatusApplied, Version: 2}
+	second := Entry{Path: "app/two", Status: StatusUnchanged, Version: 5}
+
+	tests := []struct {
+		name        string
+		content     func(t *testing.T) string
+		wantResumed bool
+		wantErr     string
+		wantPaths   []string
+	}{
+		{
+			name:        "no journal",
+			wantResumed: false,
+		},
+		{
+			name:        "empty journal",
+			content:     func(t *testing.T) string { return "" },
+			wantResumed: false,
+		},
+		{
+			name: "torn header",
+			content: func(t *testing.T) string {
+				return strings.TrimSuffix(jsonLine(t, header), "\n")
+			},
+			wantResumed: false,
+		},
+		{
+			name: "complete journal",
+			content: func(t *testing.T) string {
+				return jsonLine(t, header) + jsonLine(t, first) + jsonLine(t, second)
+			},
+			wantResumed: true,
+			wantPaths:   []string{"app/one", "app/two"},
+		},
+		{
+			name: "torn last line",
+			content: func(t *testing.T) string {
+				torn := jsonLine(t, second)
+				return jsonLine(t, header) + jsonLine(t, first) + torn[:len(torn)/2]
+			},
+			wantResumed: true,
+			wantPaths:   []string{"app/one"},
+		},
+		{
+			name: "last entry without newline",
+			content: func(t *testing.T) string {
+				return jsonLine(t, header) + jsonLine(t, first) + strings.TrimSuffix(jsonLine(t, second), "\n")
+			},
+			wantResumed: true,
+			wantPaths:   []string{"app/one"},
+		},
+		{
+			name: "header of another run",
+			content: func(t *testing.T) string {
+				return jsonLine(t, otherMount) + jsonLine(t, first)
+			},
+			wantErr: "rerun without --resume",
+		},
+		{
+			name: "garbled header",
+			content: func(t *testing.T) string {
+				return "not json\n"
+			},
+			wantErr: "parse journal header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "secrets.push-journal")
+			if tt.content != nil {
+				if err := os.WriteFile(path, []byte(tt.content(t)), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			j, resumed, err := Open(path, header, true)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Open() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			defer j.Close()
+
+			if resumed != tt.wantResumed {
+				t.Errorf("Open() resumed = %v, want %v", resumed, tt.wantResumed)
+			}
+			if j.Len() != len(tt.wantPaths) {
+				t.Errorf("Len() = %d, want %d", j.Len(), len(tt.wantPaths))
+			}
+			for _, p := range tt.wantPaths {
+				if _, ok := j.Lookup(p); !ok {
+					t.Errorf("Lookup(%q) found no entry", p)
+				}
+			}
+		})
+	}
+}
+
+func TestResumeAfterTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.push-journal")
+	header := testHeader()
+
+	j, _, err := Open(path, header, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(Entry{Path: "app/one", Status: StatusApplied, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// A crash while writing the second entry
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"path":"app/tw`)
+	f.Close()
+
+	j, resumed, err := Open(path, header, true)
+	if err != nil || !resumed {
+		t.Fatalf("Open() = %v, %v, want a resumed journal", resumed, err)
+	}
+	for _, p := range []string{"app/two", "app/three"} {
+		if err := j.Record(Entry{Path: p, Status: StatusApplied, Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	// Entries recorded after the torn line survive the next resume
+	j, resumed, err = Open(path, header, true)
+	if err != nil || !resumed {
+		t.Fatalf("Open() = %v, %v, want a resumed journal", resumed, err)
+	}
+	defer j.Close()
+	for _, p := range []string{"app/one", "app/two", "app/three"} {
+		if _, ok := j.Lookup(p); !ok {
+			t.Errorf("Lookup(%q) found no entry after resuming twice", p)
+		}
+	}
+	if j.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", j.Len())
+	}
+}
+
+func TestOpenWithoutResumeReplacesJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.pull-journal")
+	header := testHeader()
+	header.Operation = "pull"
+
+	j, _, err := Open(path, header, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Record(Entry{Path: "app/one", Status: StatusApplied, Version: 1}); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	j, resumed, err := Open(path, header, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	if resumed || j.Len() != 0 {
+		t.Errorf("Open() without resume = %v with %d entries, want a new journal", resumed, j.Len())
+	}
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	if err := j.Record(Entry{Path: "app/one"}); err != nil {
+		t.Errorf("Record() on nil journal = %v", err)
+	}
+	if _, ok := j.Lookup("app/one"); ok {
+		t.Error("Lookup() on nil journal found an entry")
+	}
+	if err := j.Remove(); err != nil {
+		t.Errorf("Remove() on nil journal = %v", err)
+	}
+}
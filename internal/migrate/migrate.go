@@ -0,0 +1,383 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/transfer"
+	"vault-sync/internal/vault"
+)
+
+const (
+	statusCreate    = "create"
+	statusUpdate    = "update"
+	statusUnchanged = "unchanged"
+	statusSkipped   = "skipped"
+	statusDeclined  = "declined"
+	statusCreated   = "created"
+	statusUpdated   = "updated"
+)
+
+// migration is the planned migration of a single secret.
+type migration struct {
+	srcPath        string
+	dstPath        string
+	data           map[string]string
+	customMetadata map[string]string
+	// current is the destination secret, nil if it does not exist.
+	current    *vault.Secret
+	dstVersion int64
+	status     string
+}
+
+type Migrator struct {
+	src       *vault.Client
+	srcConfig *config.Config
+	dst       *vault.Client
+	dstConfig *config.Config
+}
+
+func New(src *vault.Client, srcCfg *config.Config, dst *vault.Client, dstCfg *config.Config) *Migrator {
+	return &Migrator{
+		src:       src,
+		srcConfig: srcCfg,
+		dst:       dst,
+		dstConfig: dstCfg,
+	}
+}
+
+// Migrate copies every secret below the source base path to the same
+// relative path below the destination base path. Each change is shown as a
+// diff and approved like a push, and the written secrets are read back and
+// compared with the source at the end.
+func (m *Migrator) Migrate(ctx context.Context, onConflict transfer.ConflictPolicy) error {
+	start := time.Now()
+	srcLocation := m.srcConfig.Location()
+	dstLocation := m.dstConfig.Location()
+
+	logger.InfoCtx(ctx, "Starting migrate operation",
+		"source", srcLocation.String(),
+		"destination", dstLocation.String(),
+		"on_conflict", string(onConflict),
+		"dry_run", m.srcConfig.DryRun)
+
+	if err := checkLocations(srcLocation, dstLocation); err != nil {
+		return errors.New("check_migrate_locations", err).
+			WithContext("source", srcLocation.String()).
+			WithContext("destination", dstLocation.String())
+	}
+
+	fmt.Printf("Migrating %s\n       to %s\n\n", srcLocation, dstLocation)
+
+	plan, err := m.plan(ctx)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []*migration
+	for _, mig := range plan {
+		if mig.status == statusUpdate {
+			conflicts = append(conflicts, mig)
+		}
+	}
+	if len(conflicts) > 0 {
+		switch onConflict {
+		case transfer.ConflictFail:
+			fmt.Println("Destination secrets already exist with different data:")
+			for _, mig := range conflicts {
+				fmt.Printf("  %s\n", mig.dstPath)
+			}
+			return errors.New("migrate_conflict",
+				fmt.Errorf("%d destination secrets already exist; use --on-conflict skip or overwrite", len(conflicts))).
+				WithContext("destination", dstLocation.String())
+		case transfer.ConflictSkip:
+			for _, mig := range conflicts {
+				mig.status = statusSkipped
+			}
+		}
+	}
+
+	for _, mig := range plan {
+		if err := ctx.Err(); err != nil {
+			m.printReport(plan)
+			return err
+		}
+		if err := m.migrateSecret(ctx, mig); err != nil {
+			m.printReport(plan)
+			return err
+		}
+	}
+
+	m.printReport(plan)
+
+	if m.srcConfig.DryRun {
+		logger.InfoCtx(ctx, "Dry run migrate completed",
+			"secrets", len(plan),
+			"duration_ms", time.Since(start).Milliseconds())
+		return nil
+	}
+
+	mismatches, err := m.verify(ctx, plan)
+	if err != nil {
+		return err
+	}
+
+	logger.InfoCtx(ctx, "Migrate operation completed",
+		"secrets", len(plan),
+		"verification_failures", mismatches,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	if mismatches > 0 {
+		return errors.New("verify_migration",
+			fmt.Errorf("%d migrated secrets do not match the source", mismatches)).
+			WithContext("destination", dstLocation.String())
+	}
+	return nil
+}
+
+func (m *Migrator) plan(ctx context.Context) ([]*migration, error) {
+	srcBase := strings.Trim(m.srcConfig.BasePath, "/")
+	dstBase := strings.Trim(m.dstConfig.BasePath, "/")
+
+	var plan []*migration
+	err := m.src.WalkSecrets(ctx, srcBase, func(srcPath string) error {
+		relative := strings.TrimPrefix(strings.TrimPrefix(srcPath, srcBase), "/")
+		dstPath := strings.TrimPrefix(dstBase+"/"+relative, "/")
+
+		secret, err := m.src.ReadSecret(ctx, srcPath)
+		if err != nil {
+			if vault.IsNotFound(err) {
+				fmt.Printf("- %s has no readable current version, skipping\n", srcPath)
+				return nil
+			}
+			return errors.WrapWithPath(err, "read_source_secret", srcPath)
+		}
+
+		mig := &migration{
+			srcPath: srcPath,
+			dstPath: dstPath,
+			data:    secret.Data,
+			status:  statusCreate,
+		}
+
+		metadata, err := m.src.ReadMetadata(ctx, srcPath)
+		switch {
+		case err == nil:
+			mig.customMetadata = metadata.CustomMetadata
+		case !vault.IsUnsupported(err):
+			return errors.WrapWithPath(err, "read_source_metadata", srcPath)
+		}
+
+		dstMetadata, err := m.dst.ReadMetadata(ctx, dstPath)
+		switch {
+		case err == nil:
+			mig.dstVersion = dstMetadata.CurrentVersion
+			current, err := m.dst.ReadSecret(ctx, dstPath)
+			switch {
+			case err == nil:
+				mig.current = current
+			case vault.IsNotFound(err):
+				// The latest version is deleted; treat it as an empty secret.
+				mig.current = &vault.Secret{Path: dstPath, Data: make(map[string]string)}
+			default:
+				return errors.WrapWithPath(err, "read_destination_secret", dstPath)
+			}
+		case vault.IsUnsupported(err):
+			// KV v1 keeps no metadata, so the secret exists if it can be read.
+			current, err := m.dst.ReadSecret(ctx, dstPath)
+			switch {
+			case err == nil:
+				mig.current = current
+			case !vault.IsNotFound(err):
+				return errors.WrapWithPath(err, "read_destination_secret", dstPath)
+			}
+		case !vault.IsNotFound(err):
+			return errors.WrapWithPath(err, "read_destination_metadata", dstPath)
+		}
+
+		if mig.current != nil {
+			mig.status = statusUpdate
+			if equalData(mig.current.Data, mig.data) {
+				mig.status = statusUnchanged
+			}
+		}
+
+		plan = append(plan, mig)
+		return nil
+	})
+	return plan, err
+}
+
+func (m *Migrator) migrateSecret(ctx context.Context, mig *migration) error {
+	if mig.status == statusUnchanged {
+		fmt.Printf("✓ No changes needed for %s\n", mig.dstPath)
+		return nil
+	}
+	if mig.status == statusSkipped {
+		fmt.Printf("- %s already exists, skipping\n", mig.dstPath)
+		return nil
+	}
+
+	fmt.Printf("\nMigrating: %s -> %s\n", mig.srcPath, mig.dstPath)
+
+	current := mig.current
+	if current == nil {
+		fmt.Printf("Secret %s does not exist at the destination (will create new)\n", mig.dstPath)
+		current = &vault.Secret{Path: mig.dstPath, Data: make(map[string]string)}
+	}
+	proposed := &vault.Secret{Path: mig.dstPath, Data: mig.data}
+
+	secretDiff, err := diff.CompareSecrets(current, proposed)
+	if err != nil {
+		return errors.WrapWithPath(err, "compare_secrets", mig.dstPath)
+	}
+	diff.PrintDiff(secretDiff)
+	if len(mig.customMetadata) > 0 && m.dst.KVVersion() == 1 {
+		fmt.Printf("Note: the destination is KV v1, %d custom metadata keys are not copied\n", len(mig.customMetadata))
+	}
+
+	if m.srcConfig.DryRun {
+		fmt.Printf("✓ [DRY RUN] Would %s %s\n", mig.status, mig.dstPath)
+		return nil
+	}
+
+	if !m.srcConfig.AutoApprove {
+		if !prompt.Confirm(ctx, fmt.Sprintf("Migrate %s to %s?", mig.srcPath, mig.dstPath)) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.InfoCtx(ctx, "User declined migration of secret", "path", mig.srcPath)
+			fmt.Printf("✗ Skipped %s\n", mig.dstPath)
+			mig.status = statusDeclined
+			return nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	writeCtx := context.WithoutCancel(ctx)
+	if m.dst.KVVersion() == 1 {
+		err = m.dst.WriteSecret(writeCtx, proposed)
+	} else {
+		err = m.dst.WriteSecretCAS(writeCtx, proposed, mig.dstVersion)
+	}
+	if err != nil {
+		return errors.WrapWithPath(err, "write_destination_secret", mig.dstPath)
+	}
+	if m.dst.KVVersion() == 2 && (len(mig.customMetadata) > 0 || mig.current != nil) {
+		if err := m.dst.WriteCustomMetadata(writeCtx, mig.dstPath, mig.customMetadata); err != nil {
+			return errors.WrapWithPath(err, "write_destination_metadata", mig.dstPath)
+		}
+	}
+
+	if mig.status == statusCreate {
+		mig.status = statusCreated
+	} else {
+		mig.status = statusUpdated
+	}
+
+	logger.InfoCtx(ctx, "Migrated secret",
+		"source", mig.srcPath,
+		"destination", mig.dstPath,
+		"status", mig.status,
+		"version", proposed.Version)
+
+	fmt.Printf("✓ Migrated %s\n", mig.dstPath)
+	return nil
+}
+
+// verify reads every migrated or unchanged secret back from the destination
+// and compares it with the data read from the source.
+func (m *Migrator) verify(ctx context.Context, plan []*migration) (int, error) {
+	fmt.Println("\nVerifying destination:")
+
+	checked, mismatches := 0, 0
+	for _, mig := range plan {
+		if mig.status != statusCreated && mig.status != statusUpdated && mig.status != statusUnchanged {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return mismatches, err
+		}
+
+		checked++
+		secret, err := m.dst.ReadSecret(ctx, mig.dstPath)
+		if err != nil {
+			if !vault.IsNotFound(err) {
+				return mismatches, errors.WrapWithPath(err, "verify_destination_secret", mig.dstPath)
+			}
+			secret = &vault.Secret{Path: mig.dstPath}
+		}
+
+		if !equalData(secret.Data, mig.data) {
+			mismatches++
+			fmt.Printf("  ✗ %s does not match the source\n", mig.dstPath)
+			diff.PrintKeyChanges(diff.MaskValues(diff.CompareKeys(mig.data, secret.Data)))
+		}
+	}
+
+	if mismatches == 0 {
+		fmt.Printf("  ✓ All %d secrets match the source\n", checked)
+	} else {
+		fmt.Printf("  %d of %d secrets do not match the source\n", mismatches, checked)
+	}
+	return mismatches, nil
+}
+
+func (m *Migrator) printReport(plan []*migration) {
+	counts := make(map[string]int)
+	for _, mig := range plan {
+		counts[mig.status]++
+	}
+
+	fmt.Println("\nMigration report:")
+	if m.srcConfig.DryRun {
+		fmt.Printf("  Would create:  %d\n", counts[statusCreate])
+		fmt.Printf("  Would update:  %d\n", counts[statusUpdate])
+	} else {
+		fmt.Printf("  Created:       %d\n", counts[statusCreated])
+		fmt.Printf("  Updated:       %d\n", counts[statusUpdated])
+		if pending := counts[statusCreate] + counts[statusUpdate]; pending > 0 {
+			fmt.Printf("  Not migrated:  %d\n", pending)
+		}
+	}
+	fmt.Printf("  Unchanged:     %d\n", counts[statusUnchanged])
+	fmt.Printf("  Skipped:       %d\n", counts[statusSkipped])
+	fmt.Printf("  Declined:      %d\n", counts[statusDeclined])
+}
+
+func checkLocations(src, dst config.Location) error {
+	if !src.SameMount(dst) {
+		return nil
+	}
+	srcPath := strings.Trim(src.Path, "/")
+	dstPath := strings.Trim(dst.Path, "/")
+	if srcPath == dstPath {
+		return fmt.Errorf("source and destination are the same")
+	}
+	if srcPath == "" || strings.HasPrefix(dstPath+"/", srcPath+"/") {
+		return fmt.Errorf("destination is inside the source tree")
+	}
+	return nil
+}
+
+func equalData(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if other, ok := b[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	startReader sync.Once
+	requests    chan struct{}
+	lines       chan string
+)
+
+// ReadLine reads one line from stdin. All prompts share a single reader so
+// answers piped in ahead of time are not lost between questions. The reader
+// only reads while a line is asked for, so stdin is left alone in between,
+// for example for an editor. It returns ctx.Err() as soon as ctx is
+// cancelled and io.EOF once stdin is closed.
+func ReadLine(ctx context.Context) (string, error) {
+	startReader.Do(func() {
+		requests = make(chan struct{}, 1)
+		lines = make(chan string)
+		go readLines()
+	})
+
+	// A read left pending by a cancelled call still answers this one
+	select {
+	case requests <- struct{}{}:
+	default:
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-lines:
+		if !ok {
+			return "", io.EOF
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+}
+
+func readLines() {
+	reader := bufio.NewReader(os.Stdin)
+	for range requests {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			lines <- line
+		}
+		if err != nil {
+			close(lines)
+			return
+		}
+	}
+}
+
+// Confirm asks a yes/no question and returns true only for "y" or "yes".
+// Cancellation and end of input count as "no".
+func Confirm(ctx context.Context, question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+
+	response, err := ReadLine(ctx)
+	if err != nil {
+		fmt.Println()
+		return false
+	}
+
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
@@ -5,25 +5,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"vault-sync/internal/codec"
 	"vault-sync/internal/config"
 	"vault-sync/internal/errors"
+	"vault-sync/internal/fsutil"
+	"vault-sync/internal/journal"
 	"vault-sync/internal/logger"
+	"vault-sync/internal/sidecar"
 	"vault-sync/internal/vault"
 )
 
+// pullResult is the outcome of pulling a single secret.
+type pullResult int
+
+const (
+	resultPulled pullResult = iota
+	// resultReused means a resumed journal entry was still valid and the
+	// staged file was kept as is.
+	resultReused
+	// resultSkipped means the secret had no version to pull at --as-of.
+	resultSkipped
+)
+
 type Puller struct {
-	client *vault.Client
-	config *config.Config
+	client  *vault.Client
+	config  *config.Config
+	codec   codec.Codec
+	journal *journal.Journal
+	// metadataUnavailable is set once reading metadata failed, so the
+	// notice that sidecars are not written is only printed once.
+	metadataUnavailable bool
 }
 
-func New(client *vault.Client, cfg *config.Config) *Puller {
+func New(client *vault.Client, cfg *config.Config, fileCodec codec.Codec) *Puller {
 	return &Puller{
 		client: client,
 		config: cfg,
+		codec:  fileCodec,
 	}
 }
 
@@ -33,89 +55,540 @@ func (p *Puller) Pull(ctx context.Context) error {
 		"output_dir", p.config.OutputDir,
 		"base_path", p.config.BasePath)
 	
-	fmt.Printf("Pulling secrets from Vault to %s\n", p.config.OutputDir)
-	
-	if err := os.MkdirAll(p.config.OutputDir, 0755); err != nil {
-		return errors.New("create_output_dir", err).
-			WithContext("output_dir", p.config.OutputDir)
+	if p.config.SecretVersion > 0 {
+		return p.pullVersion(ctx, start)
 	}
 
-	logger.DebugCtx(ctx, "Created output directory", "path", p.config.OutputDir)
+	asOf := ""
+	if !p.config.AsOf.IsZero() {
+		asOf = p.config.AsOf.UTC().Format(time.RFC3339)
+		fmt.Printf("Pulling secrets from Vault as of %s to %s\n", asOf, p.config.OutputDir)
+	} else {
+		fmt.Printf("Pulling secrets from Vault to %s\n", p.config.OutputDir)
+	}
+
+	// Completed paths are journaled so an interrupted pull can be resumed
+	// into the same staging tree with --resume. Both live next to the output
+	// directory, so its parent has to exist.
+	outputDir := filepath.Clean(p.config.OutputDir)
+	if err := os.MkdirAll(filepath.Dir(outputDir), 0755); err != nil {
+		return errors.New("create_output_parent_dir", err).
+			WithContext("output_dir", outputDir)
+	}
+	pullJournal, resumed, err := journal.Open(outputDir+".pull-journal", journal.Header{
+		Operation: "pull",
+		VaultAddr: p.config.VaultAddr,
+		Namespace: p.config.VaultNamespace,
+		Mount:     p.config.KVMount,
+		BasePath:  p.config.BasePath,
+		OutputDir: outputDir,
+		AsOf:      asOf,
+		StartedAt: time.Now().UTC(),
+	}, p.config.Resume)
+	if err != nil {
+		return errors.New("open_journal", err).
+			WithContext("output_dir", outputDir)
+	}
+	p.journal = pullJournal
+
+	if resumed {
+		logger.InfoCtx(ctx, "Resuming previous pull", "completed_entries", pullJournal.Len())
+		fmt.Printf("Resuming previous pull (%d secrets already pulled)\n", pullJournal.Len())
+	} else if p.config.Resume {
+		fmt.Println("No interrupted pull to resume, starting a new one")
+	}
+
+	// Secrets are written to a staging tree next to the output directory and
+	// swapped in only once the whole walk has succeeded, so an interrupted
+	// pull never leaves a partial tree behind for the next push.
+	stagingDir := p.stagingDir()
+	if !resumed {
+		if err := os.RemoveAll(stagingDir); err != nil {
+			pullJournal.Close()
+			return errors.New("remove_staging_dir", err).
+				WithContext("staging_dir", stagingDir)
+		}
+	}
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		pullJournal.Close()
+		return errors.New("create_staging_dir", err).
+			WithContext("staging_dir", stagingDir)
+	}
+
+	logger.DebugCtx(ctx, "Created staging directory", "path", stagingDir)
 
 	secretCount := 0
-	err := p.client.WalkSecrets(ctx, p.config.BasePath, func(secretPath string) error {
-		if err := p.pullSecret(ctx, secretPath); err != nil {
+	skippedCount := 0
+	listed := false
+	err = p.client.WalkSecrets(ctx, p.config.BasePath, func(secretPath string) error {
+		listed = true
+		result, err := p.pullSecret(ctx, stagingDir, secretPath)
+		if err != nil {
 			return errors.WrapWithPath(err, "pull_secret", secretPath)
 		}
+		if result == resultSkipped {
+			skippedCount++
+			return nil
+		}
 		secretCount++
-		logger.DebugCtx(ctx, "Pulled secret", "path", secretPath, "count", secretCount)
-		fmt.Printf("✓ Pulled: %s\n", secretPath)
+		logger.DebugCtx(ctx, "Pulled secret", "path", secretPath, "count", secretCount, "resumed", result == resultReused)
+		if result == resultReused {
+			fmt.Printf("✓ Already pulled: %s\n", secretPath)
+		} else {
+			fmt.Printf("✓ Pulled: %s\n", secretPath)
+		}
 		return nil
 	})
 
+	// Vault answers a list of an empty mount with a 404; a missing base
+	// path, or a secret that cannot be read, is still an error.
+	if err != nil && !listed && vault.IsNotFound(err) && strings.Trim(p.config.BasePath, "/") == "" {
+		logger.InfoCtx(ctx, "Mount has no secrets", "mount", p.config.KVMount)
+		err = nil
+	}
+
+	if err == nil {
+		err = p.swapIn(ctx, stagingDir)
+	}
+
 	if err != nil {
-		logger.ErrorCtx(ctx, "Pull operation failed", 
+		// The staging tree and journal are kept so the pull can be resumed
+		pullJournal.Close()
+		if ctx.Err() != nil {
+			fmt.Printf("\nPull interrupted after %d secrets; %s was left unchanged\n", secretCount, p.config.OutputDir)
+		}
+		fmt.Println("Run 'vault-sync pull --resume' to continue where this pull stopped")
+		logger.ErrorCtx(ctx, "Pull operation failed, existing local tree left untouched", 
 			"error", err,
+			"output_dir", p.config.OutputDir,
 			"secrets_pulled", secretCount,
 			"duration_ms", time.Since(start).Milliseconds())
 		return err
 	}
 
+	if err := pullJournal.Remove(); err != nil {
+		logger.WarnCtx(ctx, "Failed to remove pull journal", "error", err)
+	}
+
 	logger.InfoCtx(ctx, "Pull operation completed successfully", 
 		"secrets_pulled", secretCount,
+		"secrets_skipped", skippedCount,
 		"duration_ms", time.Since(start).Milliseconds())
 	
-	fmt.Printf("\nSuccessfully pulled %d secrets\n", secretCount)
+	if skippedCount > 0 && asOf != "" {
+		fmt.Printf("\nSuccessfully pulled %d secrets (%d skipped as of %s)\n", secretCount, skippedCount, asOf)
+	} else if skippedCount > 0 {
+		fmt.Printf("\nSuccessfully pulled %d secrets (%d skipped)\n", secretCount, skippedCount)
+	} else {
+		fmt.Printf("\nSuccessfully pulled %d secrets\n", secretCount)
+	}
+	return nil
+}
+
+// pullVersion writes a single version of the secret at BasePath into the
+// output directory, at the path a pull of the whole mount would write it to,
+// so a plain push or a push with the same base path maps that file back to
+// the secret. Unlike a tree pull, the rest of the output directory is left as
+// it is.
+func (p *Puller) pullVersion(ctx context.Context, start time.Time) error {
+	secretPath := p.config.BasePath
+	version := p.config.SecretVersion
+
+	fmt.Printf("Pulling version %d of %s to %s\n", version, secretPath, p.config.OutputDir)
+
+	metadata, err := p.client.ReadMetadata(ctx, secretPath)
+	if err != nil {
+		return errors.WrapWithPath(err, "read_metadata", secretPath)
+	}
+
+	info, ok := metadata.Version(version)
+	switch {
+	case !ok:
+		return errors.NewWithPath("pull_version", secretPath, fmt.Errorf("version %d does not exist or is no longer kept", version)).
+			WithContext("current_version", metadata.CurrentVersion)
+	case info.Destroyed:
+		return errors.NewWithPath("pull_version", secretPath, fmt.Errorf("version %d has been destroyed", version))
+	case info.Deleted():
+		return errors.NewWithPath("pull_version", secretPath, fmt.Errorf("version %d has been deleted", version)).
+			WithContext("deletion_time", info.DeletionTime)
+	}
+
+	secret, err := p.client.ReadSecretVersion(ctx, secretPath, version)
+	if err != nil {
+		return errors.WrapWithPath(err, "read_secret_version", secretPath)
+	}
+
+	localPath := filepath.Join(filepath.Clean(p.config.OutputDir), filepath.FromSlash(strings.Trim(secretPath, "/"))+p.codec.Extension())
+	if err := checkLocalPath(secretPath, localPath); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return errors.New("create_output_dir", err).
+			WithContext("output_dir", filepath.Dir(localPath))
+	}
+
+	fileData, err := p.encode(ctx, secret.Data, localPath)
+	if err != nil {
+		return errors.New("encode_secret", err).
+			WithContext("secret_path", secretPath).
+			WithContext("key_count", len(secret.Data))
+	}
+
+	if err := fsutil.WriteFileAtomic(localPath, fileData, 0600); err != nil {
+		return errors.New("write_file", err).
+			WithContext("local_path", localPath).
+			WithContext("secret_path", secretPath)
+	}
+
+	logger.InfoCtx(ctx, "Pulled secret version",
+		"path", secretPath,
+		"version", version,
+		"local_path", localPath,
+		"key_count", len(secret.Data),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	fmt.Printf("✓ Pulled: %s (version %d) to %s\n", secretPath, version, localPath)
 	return nil
 }
 
-func (p *Puller) pullSecret(ctx context.Context, secretPath string) error {
+// pullSecret writes one secret into rootDir.
+func (p *Puller) pullSecret(ctx context.Context, rootDir, secretPath string) (pullResult, error) {
 	start := time.Now()
 	logger.DebugCtx(ctx, "Pulling secret", "path", secretPath)
 	
-	secret, err := p.client.ReadSecret(ctx, secretPath)
+	localPath := p.getLocalPath(rootDir, secretPath)
+	if err := checkLocalPath(secretPath, localPath); err != nil {
+		return resultPulled, err
+	}
+
+	// Metadata is only needed for the sidecar unless pulling --as-of, so a
+	// token without read access to it can still pull data.
+	metadata, err := p.client.ReadMetadata(ctx, secretPath)
 	if err != nil {
-		return errors.WrapWithPath(err, "read_secret", secretPath)
+		if !p.config.AsOf.IsZero() || ctx.Err() != nil {
+			return resultPulled, errors.WrapWithPath(err, "read_metadata", secretPath)
+		}
+		// KV v1 has no metadata, so there is nothing to warn about
+		if !vault.IsUnsupported(err) {
+			p.warnMetadataUnavailable(ctx, secretPath, err)
+		}
+		metadata = nil
+	}
+
+	secret, err := p.readSecret(ctx, secretPath, metadata)
+	if err != nil {
+		return resultPulled, err
+	}
+	if secret == nil {
+		return resultSkipped, nil
+	}
+
+	// KV v1 secrets have no version that would tell whether they changed
+	if entry, ok := p.journal.Lookup(secretPath); ok && secret.Version > 0 && entry.Version == secret.Version {
+		if _, err := os.Stat(localPath); err == nil {
+			logger.DebugCtx(ctx, "Secret unchanged since it was pulled, keeping staged file",
+				"path", secretPath,
+				"version", secret.Version)
+			// Metadata changes do not create a new version, so the sidecar
+			// is refreshed anyway.
+			return resultReused, p.writeSidecar(localPath, secretPath, metadata)
+		}
 	}
 
-	localPath := p.getLocalPath(secretPath)
 	logger.DebugCtx(ctx, "Writing to local file", "local_path", localPath)
 	
 	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
-		return errors.New("create_local_dir", err).
+		return resultPulled, errors.New("create_local_dir", err).
 			WithContext("local_path", localPath).
 			WithContext("secret_path", secretPath)
 	}
 
-	yamlData, err := yaml.Marshal(secret.Data)
+	// The file in the output directory is rewritten, unless a resumed pull
+	// already staged a newer one
+	fileData, err := p.encode(ctx, secret.Data, localPath, p.getLocalPath(filepath.Clean(p.config.OutputDir), secretPath))
 	if err != nil {
-		return errors.New("marshal_yaml", err).
+		return resultPulled, errors.New("encode_secret", err).
 			WithContext("secret_path", secretPath).
 			WithContext("key_count", len(secret.Data))
 	}
 
-	if err := os.WriteFile(localPath, yamlData, 0600); err != nil {
-		return errors.New("write_file", err).
+	if err := fsutil.WriteFileAtomic(localPath, fileData, 0600); err != nil {
+		return resultPulled, errors.New("write_file", err).
 			WithContext("local_path", localPath).
 			WithContext("secret_path", secretPath)
 	}
 
+	if err := p.writeSidecar(localPath, secretPath, metadata); err != nil {
+		return resultPulled, err
+	}
+
 	logger.DebugCtx(ctx, "Successfully pulled secret", 
 		"path", secretPath,
 		"local_path", localPath,
 		"key_count", len(secret.Data),
-		"file_size", len(yamlData),
+		"file_size", len(fileData),
 		"duration_ms", time.Since(start).Milliseconds())
 
+	if err := p.journal.Record(journal.Entry{
+		Path:    secretPath,
+		Status:  journal.StatusApplied,
+		Version: secret.Version,
+	}); err != nil {
+		return resultPulled, errors.New("record_journal", err).
+			WithContext("secret_path", secretPath)
+	}
+
+	return resultPulled, nil
+}
+
+// writeSidecar writes the custom metadata of a secret next to its file, or
+// removes a stale sidecar when there is none. Nothing is changed when the
+// metadata could not be read.
+func (p *Puller) writeSidecar(localPath, secretPath string, metadata *vault.SecretMetadata) error {
+	if metadata == nil {
+		return nil
+	}
+
+	sidecarPath := sidecar.PathFor(localPath, p.codec.Extension())
+	file := sidecar.FromMetadata(metadata)
+	if file == nil {
+		if err := os.Remove(sidecarPath); err != nil && !os.IsNotExist(err) {
+			return errors.New("remove_sidecar", err).
+				WithContext("sidecar_path", sidecarPath).
+				WithContext("secret_path", secretPath)
+		}
+		return nil
+	}
+
+	if err := sidecar.Save(sidecarPath, file); err != nil {
+		return errors.New("write_sidecar", err).
+			WithContext("sidecar_path", sidecarPath).
+			WithContext("secret_path", secretPath)
+	}
+	return nil
+}
+
+// encode encodes data for a local file. Codecs that keep settings in the
+// file, such as the key groups of a sops file, rewrite the first of
+// previousPaths that exists rather than starting afresh.
+func (p *Puller) encode(ctx context.Context, data map[string]string, previousPaths ...string) ([]byte, error) {
+	if rewriter, ok := p.codec.(codec.Rewriter); ok {
+		for _, previousPath := range previousPaths {
+			previous, err := os.ReadFile(previousPath)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return rewriter.Reencode(ctx, previous, data)
+		}
+	}
+	return p.codec.Encode(ctx, data)
+}
+
+func (p *Puller) warnMetadataUnavailable(ctx context.Context, secretPath string, err error) {
+	logger.WarnCtx(ctx, "Cannot read secret metadata, skipping metadata sidecar", "path", secretPath, "error", err)
+	if !p.metadataUnavailable {
+		p.metadataUnavailable = true
+		fmt.Println("Note: secret metadata cannot be read, custom metadata is not pulled")
+	}
+}
+
+// readSecret reads the version of a secret to pull: the latest one, or with
+// --as-of the one that was current at that time, as found in metadata. It
+// returns a nil secret when there is nothing to pull at --as-of.
+func (p *Puller) readSecret(ctx context.Context, secretPath string, metadata *vault.SecretMetadata) (*vault.Secret, error) {
+	if p.config.AsOf.IsZero() {
+		// A secret whose latest version is deleted or destroyed cannot be
+		// read; its local file, if any, is kept as it is
+		if metadata != nil {
+			if current, ok := metadata.Version(metadata.CurrentVersion); ok && !current.Readable() {
+				reason := fmt.Sprintf("latest version %d has been deleted", current.Version)
+				if current.Destroyed {
+					reason = fmt.Sprintf("latest version %d has been destroyed", current.Version)
+				}
+				logger.InfoCtx(ctx, "Skipping secret without readable latest version",
+					"path", secretPath,
+					"reason", reason)
+				fmt.Printf("- Skipped %s: %s\n", secretPath, reason)
+				return nil, nil
+			}
+		}
+		secret, err := p.client.ReadSecret(ctx, secretPath)
+		if err != nil {
+			return nil, errors.WrapWithPath(err, "read_secret", secretPath)
+		}
+		return secret, nil
+	}
+
+	asOf := p.config.AsOf.UTC().Format(time.RFC3339)
+	info, ok := metadata.VersionAt(p.config.AsOf)
+
+	var reason string
+	switch {
+	case !ok:
+		reason = "no version kept from before " + asOf
+	case info.Deleted() && !info.DeletionTime.After(p.config.AsOf):
+		reason = fmt.Sprintf("version %d was already deleted at %s", info.Version, asOf)
+	case info.Destroyed:
+		reason = fmt.Sprintf("version %d has since been destroyed", info.Version)
+	case info.Deleted():
+		reason = fmt.Sprintf("version %d has since been deleted", info.Version)
+	}
+	if reason != "" {
+		logger.InfoCtx(ctx, "Skipping secret without readable version at as-of time",
+			"path", secretPath,
+			"as_of", asOf,
+			"reason", reason)
+		fmt.Printf("- Skipped %s: %s\n", secretPath, reason)
+		return nil, nil
+	}
+
+	secret, err := p.client.ReadSecretVersion(ctx, secretPath, info.Version)
+	if err != nil {
+		return nil, errors.WrapWithPath(err, "read_secret_version", secretPath)
+	}
+	return secret, nil
+}
+
+// swapIn replaces the output directory with the completed staging tree.
+// Only the files of pulled secrets are replaced; everything else in the
+// existing output directory (dotfiles such as .git, local secrets not pushed
+// yet, the directories of other syncs, ...) is carried over into the new tree.
+func (p *Puller) swapIn(ctx context.Context, stagingDir string) error {
+	outputDir := filepath.Clean(p.config.OutputDir)
+
+	for _, name := range p.config.ExcludeDirs {
+		if _, err := os.Stat(filepath.Join(stagingDir, name)); err == nil {
+			return errors.New("check_excluded_dirs",
+				fmt.Errorf("secrets below %s/ collide with %s, which belongs to the sync of a child namespace", name, name)).
+				WithContext("output_dir", outputDir).
+				WithContext("base_path", p.config.BasePath)
+		}
+	}
+
+	// A previous swap may have been interrupted with the old tree moved aside
+	if err := fsutil.RecoverSwap(outputDir); err != nil {
+		return errors.New("recover_output_dir", err).
+			WithContext("output_dir", outputDir)
+	}
+
+	var carried []string
+	if err := p.carryOver(outputDir, stagingDir, "", &carried); err != nil {
+		p.restoreCarried(ctx, stagingDir, outputDir, carried)
+		return errors.New("preserve_local_file", err).
+			WithContext("output_dir", outputDir)
+	}
+
+	if err := fsutil.SwapDir(stagingDir, outputDir); err != nil {
+		p.restoreCarried(ctx, stagingDir, outputDir, carried)
+		return errors.New("swap_output_dir", err).
+			WithContext("output_dir", outputDir).
+			WithContext("staging_dir", stagingDir)
+	}
+
+	var kept []string
+	for _, name := range carried {
+		if !strings.HasPrefix(filepath.Base(name), ".") && !slices.Contains(p.config.ExcludeDirs, name) {
+			kept = append(kept, name)
+		}
+	}
+	if len(kept) > 0 {
+		fmt.Printf("Kept %d local entries that are not in Vault:\n", len(kept))
+		for _, name := range kept {
+			fmt.Printf("  - %s\n", name)
+		}
+	}
+
+	logger.DebugCtx(ctx, "Swapped staging directory into place",
+		"output_dir", outputDir,
+		"preserved_entries", len(carried))
+
+	return nil
+}
+
+// carryOver moves every entry of outputDir/rel that the pull did not write
+// into the same place in the staging tree, and appends its relative path to
+// carried. Directories that exist in both are merged entry by entry.
+func (p *Puller) carryOver(outputDir, stagingDir, rel string, carried *[]string) error {
+	entries, err := os.ReadDir(filepath.Join(outputDir, rel))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		name := filepath.Join(rel, entry.Name())
+		staged, err := os.Lstat(filepath.Join(stagingDir, name))
+		switch {
+		case err == nil && staged.IsDir() && entry.IsDir():
+			if err := p.carryOver(outputDir, stagingDir, name, carried); err != nil {
+				return err
+			}
+			continue
+		case err == nil && staged.IsDir() != entry.IsDir():
+			return fmt.Errorf("local %s collides with a pulled secret of the same name", name)
+		case err == nil:
+			// Replaced by the pulled secret
+			continue
+		case !os.IsNotExist(err):
+			return err
+		}
+
+		// The metadata of a pulled secret is rewritten by the pull; a
+		// sidecar it did not write belongs to secret settings that were
+		// cleared in Vault
+		if !entry.IsDir() && sidecar.IsSidecar(name) {
+			secretFile := strings.TrimSuffix(name, sidecar.Suffix) + p.codec.Extension()
+			if _, err := os.Stat(filepath.Join(stagingDir, secretFile)); err == nil {
+				continue
+			}
+		}
+
+		if err := os.Rename(filepath.Join(outputDir, name), filepath.Join(stagingDir, name)); err != nil {
+			return err
+		}
+		*carried = append(*carried, name)
+	}
 	return nil
 }
 
-func (p *Puller) getLocalPath(secretPath string) string {
+// restoreCarried moves carried entries back into the output directory.
+func (p *Puller) restoreCarried(ctx context.Context, stagingDir, outputDir string, names []string) {
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(stagingDir, name), filepath.Join(outputDir, name)); err != nil {
+			logger.ErrorCtx(ctx, "Failed to restore local file", "name", name, "staging_dir", stagingDir, "error", err)
+		}
+	}
+}
+
+func (p *Puller) stagingDir() string {
+	return filepath.Clean(p.config.OutputDir) + ".staging"
+}
+
+// checkLocalPath fails for a secret whose file would be taken for a
+// metadata sidecar, such as a secret named x.meta written as x.meta.yaml,
+// which push would skip rather than write back.
+func checkLocalPath(secretPath, localPath string) error {
+	if !sidecar.IsSidecar(localPath) {
+		return nil
+	}
+	return errors.NewWithPath("check_local_path", secretPath,
+		fmt.Errorf("its file %s would be read as the metadata sidecar of another secret", filepath.Base(localPath))).
+		WithContext("local_path", localPath).
+		WithContext("hint", "rename the secret, or pull with an --encryption whose files do not end in "+sidecar.Suffix)
+}
+
+func (p *Puller) getLocalPath(rootDir, secretPath string) string {
 	cleanPath := strings.TrimPrefix(secretPath, "/")
 	if p.config.BasePath != "" {
 		cleanPath = strings.TrimPrefix(cleanPath, strings.TrimPrefix(p.config.BasePath, "/"))
 		cleanPath = strings.TrimPrefix(cleanPath, "/")
 	}
 	
-	return filepath.Join(p.config.OutputDir, cleanPath+".yaml")
+	return filepath.Join(rootDir, cleanPath+p.codec.Extension())
 }
\ No newline at end of file
@@ -0,0 +1,108 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"vault-sync/internal/errors"
+	"vault-sync/internal/journal"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/sidecar"
+	"vault-sync/internal/tui"
+	"vault-sync/internal/vault"
+)
+
+// pushReviewed compares every local secret with Vault first, lets the user
+// pick the secrets to apply in the full-screen review, then applies them.
+// It returns ErrStopped when the review is cancelled.
+func (p *Pusher) pushReviewed(ctx context.Context, localSecrets []*vault.Secret, localFiles map[string]string, sidecars map[string]*sidecar.File) ([]string, error) {
+	fmt.Printf("Comparing %d local secrets with Vault\n", len(localSecrets))
+
+	var plans []*secretPlan
+	var items []tui.Item
+	for _, localSecret := range localSecrets {
+		plan, err := p.planSecret(ctx, localSecret, localFiles[localSecret.Path], sidecars[localSecret.Path])
+		if err != nil {
+			p.journal.Close()
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			return nil, errors.WrapWithPath(err, "process_secret", localSecret.Path)
+		}
+
+		switch {
+		case plan.resumedStatus != "":
+			fmt.Printf("✓ %s (%s in previous run)\n", localSecret.Path, plan.resumedStatus)
+		case !plan.hasChanges():
+			if err := p.record(localSecret.Path, journal.StatusUnchanged, plan.current.Version, plan.fingerprint); err != nil {
+				p.journal.Close()
+				return nil, err
+			}
+		default:
+			plans = append(plans, plan)
+			items = append(items, tui.Item{
+				Path:     localSecret.Path,
+				New:      !plan.exists,
+				Diff:     plan.diff,
+				Metadata: plan.metadataChanges,
+			})
+		}
+	}
+
+	if len(plans) == 0 {
+		fmt.Println("✓ No changes needed")
+		return nil, nil
+	}
+
+	title := fmt.Sprintf("vault-sync push to %s", path.Join(p.config.VaultNamespace, p.config.KVMount))
+	approved, err := tui.Review(ctx, title, items)
+	if err != nil {
+		p.journal.Close()
+		if err == tui.ErrCancelled {
+			logger.InfoCtx(ctx, "User cancelled push review", "changed_secrets", len(plans))
+			fmt.Println("Push cancelled, nothing was applied")
+			return nil, ErrStopped
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, errors.New("review_changes", err)
+	}
+
+	var applied []string
+	for i, plan := range plans {
+		// Stop before starting on the next secret once interrupted
+		if err := ctx.Err(); err != nil {
+			var remaining []*vault.Secret
+			for j := i; j < len(plans); j++ {
+				if approved[j] {
+					remaining = append(remaining, plans[j].local)
+				}
+			}
+			p.printInterruptSummary(ctx, applied, remaining)
+			p.journal.Close()
+			return nil, err
+		}
+
+		if !approved[i] {
+			logger.InfoCtx(ctx, "User skipped secret update", "path", plan.local.Path)
+			err = p.skip(plan)
+		} else {
+			err = p.applySecret(ctx, plan)
+		}
+		if err != nil {
+			p.journal.Close()
+			logger.ErrorCtx(ctx, "Failed to process secret",
+				"path", plan.local.Path,
+				"error", err)
+			fmt.Println("Run 'vault-sync push --resume' to continue where this push stopped")
+			return nil, errors.WrapWithPath(err, "process_secret", plan.local.Path)
+		}
+		if approved[i] {
+			applied = append(applied, plan.local.Path)
+		}
+	}
+
+	return applied, nil
+}
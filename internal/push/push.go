@@ -1,31 +1,45 @@
 package push
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
-	"gopkg.in/yaml.v3"
+	"vault-sync/internal/codec"
 	"vault-sync/internal/config"
 	"vault-sync/internal/diff"
 	"vault-sync/internal/errors"
+	"vault-sync/internal/journal"
 	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/sidecar"
 	"vault-sync/internal/vault"
 )
 
 type Pusher struct {
-	client *vault.Client
-	config *config.Config
+	client  *vault.Client
+	config  *config.Config
+	codec   codec.Codec
+	journal *journal.Journal
+	// patchRejected is set once a PATCH fell back to a full write, so the
+	// notice is only printed once.
+	patchRejected bool
+	// singleFile is set when --base-path names a single secret whose file
+	// was written by pull --version; only that file is pushed, to the base
+	// path itself.
+	singleFile string
 }
 
-func New(client *vault.Client, cfg *config.Config) *Pusher {
+func New(client *vault.Client, cfg *config.Config, fileCodec codec.Codec) *Pusher {
 	return &Pusher{
 		client: client,
 		config: cfg,
+		codec:  fileCodec,
 	}
 }
 
@@ -43,20 +57,76 @@ func (p *Pusher) Push(ctx context.Context) error {
 			WithContext("output_dir", p.config.OutputDir)
 	}
 
+	singleFile, err := p.singleSecretFile(ctx)
+	if err != nil {
+		return err
+	}
+	p.singleFile = singleFile
+
 	var localSecrets []*vault.Secret
-	err := filepath.Walk(p.config.OutputDir, func(path string, info os.FileInfo, err error) error {
+	localFiles := make(map[string]string)
+	sidecars := make(map[string]*sidecar.File)
+	err = filepath.Walk(p.config.OutputDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			logger.WarnCtx(ctx, "Error walking file", "path", path, "error", err)
 			return errors.New("walk_file", err).WithContext("path", path)
 		}
 
-		if !info.IsDir() && strings.HasSuffix(path, ".yaml") {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		// Dotfiles such as .git or .sops.yaml are not managed by vault-sync
+		if path != p.config.OutputDir && strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Directories of other syncs, such as child namespaces, are pushed
+		// by those
+		if info.IsDir() && filepath.Dir(path) == filepath.Clean(p.config.OutputDir) &&
+			slices.Contains(p.config.ExcludeDirs, info.Name()) {
+			return filepath.SkipDir
+		}
+
+		// Metadata sidecars are loaded together with their secret
+		if !info.IsDir() && sidecar.IsSidecar(path) {
+			return nil
+		}
+
+		if p.singleFile != "" && !info.IsDir() && path != p.singleFile {
+			return nil
+		}
+
+		// Files of another encryption mode are not pushed with this codec
+		if !info.IsDir() && !strings.HasSuffix(path, p.codec.Extension()) {
+			if mode, ok := codec.EncryptionOf(path); ok {
+				return errors.NewWithPath("check_encryption", path,
+					fmt.Errorf("file was written with --encryption %s", mode)).
+					WithContext("encryption", p.config.Encryption).
+					WithContext("hint", "push with --encryption "+mode)
+			}
+		}
+
+		if !info.IsDir() && strings.HasSuffix(path, p.codec.Extension()) {
 			logger.DebugCtx(ctx, "Loading local secret", "path", path)
-			secret, err := p.loadLocalSecret(path)
+			secret, err := p.loadLocalSecret(ctx, path)
 			if err != nil {
 				return errors.WrapWithPath(err, "load_local_secret", path)
 			}
 			localSecrets = append(localSecrets, secret)
+			localFiles[secret.Path] = path
+
+			sidecarPath := sidecar.PathFor(path, p.codec.Extension())
+			metadata, err := sidecar.Load(sidecarPath)
+			if err != nil {
+				return errors.New("load_sidecar", err).WithContext("sidecar_path", sidecarPath)
+			}
+			if metadata != nil {
+				sidecars[secret.Path] = metadata
+			}
 		}
 
 		return nil
@@ -68,67 +138,246 @@ func (p *Pusher) Push(ctx context.Context) error {
 
 	logger.InfoCtx(ctx, "Found local secrets", "count", len(localSecrets))
 
-	pushCount := 0
+	// Decisions are journaled so an interrupted push can continue with
+	// --resume without re-prompting. Dry runs change nothing and keep no journal.
+	if !p.config.DryRun {
+		outputDir := filepath.Clean(p.config.OutputDir)
+		pushJournal, resumed, err := journal.Open(outputDir+".push-journal", journal.Header{
+			Operation: "push",
+			VaultAddr: p.config.VaultAddr,
+			Namespace: p.config.VaultNamespace,
+			Mount:     p.config.KVMount,
+			BasePath:  p.config.BasePath,
+			OutputDir: outputDir,
+			StartedAt: time.Now().UTC(),
+		}, p.config.Resume)
+		if err != nil {
+			return errors.New("open_journal", err).
+				WithContext("output_dir", outputDir)
+		}
+		p.journal = pushJournal
+
+		if resumed {
+			logger.InfoCtx(ctx, "Resuming previous push", "completed_entries", pushJournal.Len())
+			fmt.Printf("Resuming previous push (%d secrets already processed)\n", pushJournal.Len())
+		} else if p.config.Resume {
+			fmt.Println("No interrupted push to resume, starting a new one")
+		}
+	}
+
+	if p.config.TUI {
+		applied, err := p.pushReviewed(ctx, localSecrets, localFiles, sidecars)
+		if err != nil {
+			return err
+		}
+		return p.finish(ctx, start, len(localSecrets), applied)
+	}
+
+	var applied []string
 	for i, localSecret := range localSecrets {
+		// Stop before starting on the next secret once interrupted
+		if err := ctx.Err(); err != nil {
+			p.printInterruptSummary(ctx, applied, localSecrets[i:])
+			p.journal.Close()
+			return err
+		}
+
 		logger.DebugCtx(ctx, "Processing secret", 
 			"path", localSecret.Path, 
 			"progress", fmt.Sprintf("%d/%d", i+1, len(localSecrets)))
 		
-		shouldPush, err := p.processSecret(ctx, localSecret)
+		shouldPush, err := p.processSecret(ctx, localSecret, localFiles[localSecret.Path], sidecars[localSecret.Path])
 		if err != nil {
+			p.journal.Close()
+			if err == ErrStopped {
+				logger.InfoCtx(ctx, "User quit push", "path", localSecret.Path)
+				p.printInterruptSummary(ctx, applied, localSecrets[i:])
+				fmt.Println("Run 'vault-sync push --resume' to continue where this push stopped")
+				return err
+			}
+			if ctx.Err() != nil {
+				p.printInterruptSummary(ctx, applied, localSecrets[i:])
+				return err
+			}
 			logger.ErrorCtx(ctx, "Failed to process secret", 
 				"path", localSecret.Path, 
 				"error", err)
+			fmt.Println("Run 'vault-sync push --resume' to continue where this push stopped")
 			return errors.WrapWithPath(err, "process_secret", localSecret.Path)
 		}
 
 		if shouldPush {
-			pushCount++
+			applied = append(applied, localSecret.Path)
 		}
 	}
+	return p.finish(ctx, start, len(localSecrets), applied)
+}
+
+// finish removes the journal of a completed push and reports the result.
+func (p *Pusher) finish(ctx context.Context, start time.Time, total int, applied []string) error {
+	pushCount := len(applied)
+
+	if err := p.journal.Remove(); err != nil {
+		logger.WarnCtx(ctx, "Failed to remove push journal", "error", err)
+	}
 
 	logger.InfoCtx(ctx, "Push operation completed", 
-		"total_secrets", len(localSecrets),
+		"total_secrets", total,
 		"pushed_count", pushCount,
 		"duration_ms", time.Since(start).Milliseconds())
 
-	fmt.Printf("\nProcessed %d local secrets, pushed %d changes\n", len(localSecrets), pushCount)
+	fmt.Printf("\nProcessed %d local secrets, pushed %d changes\n", total, pushCount)
 	return nil
 }
 
-func (p *Pusher) processSecret(ctx context.Context, localSecret *vault.Secret) (bool, error) {
-	start := time.Now()
-	logger.DebugCtx(ctx, "Processing secret", "path", localSecret.Path)
-	
-	fmt.Printf("\nProcessing: %s\n", localSecret.Path)
+// secretPlan is what a push would change for one secret, worked out before
+// asking for approval.
+type secretPlan struct {
+	local   *vault.Secret
+	current *vault.Secret
+	exists  bool
+	// deleted is set when the secret exists but its latest version has
+	// been deleted or destroyed, so current holds no data.
+	deleted     bool
+	fingerprint string
+	// resumedStatus is the status journaled for the secret by an
+	// interrupted run, set when that decision still holds.
+	resumedStatus   string
+	diff            *diff.SecretDiff
+	metadata        *sidecar.File
+	metadataChanges []diff.KeyChange
+
+	// proposed, changes and writeData describe the data write; reviewing
+	// key by key can narrow them down.
+	proposed  *vault.Secret
+	changes   []diff.KeyChange
+	writeData bool
+}
+
+func (s *secretPlan) hasChanges() bool {
+	return s.writeData || len(s.metadataChanges) > 0
+}
+
+// planSecret compares a local secret and, when localMetadata is set, the
+// metadata declared in its sidecar with Vault.
+func (p *Pusher) planSecret(ctx context.Context, localSecret *vault.Secret, filePath string, localMetadata *sidecar.File) (*secretPlan, error) {
+	plan := &secretPlan{
+		local:    localSecret,
+		exists:   true,
+		metadata: localMetadata,
+	}
 
 	currentSecret, err := p.client.ReadSecret(ctx, localSecret.Path)
 	if err != nil {
-		logger.InfoCtx(ctx, "Secret does not exist in Vault, will create new", "path", localSecret.Path)
-		fmt.Printf("Secret %s does not exist in Vault (will create new)\n", localSecret.Path)
-		currentSecret = &vault.Secret{
-			Path: localSecret.Path,
-			Data: make(map[string]string),
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !vault.IsNotFound(err) {
+			return nil, errors.WrapWithPath(err, "read_secret", localSecret.Path)
+		}
+		// A secret whose latest version is deleted is written with
+		// check-and-set on that version, as cas_required demands
+		currentSecret, err = p.client.ReadDeletedSecret(ctx, localSecret.Path)
+		if err != nil {
+			return nil, errors.WrapWithPath(err, "read_metadata", localSecret.Path)
+		}
+		if currentSecret != nil {
+			logger.InfoCtx(ctx, "Latest version of secret is deleted, will write a new version",
+				"path", localSecret.Path,
+				"version", currentSecret.Version)
+			plan.deleted = true
+		} else {
+			logger.InfoCtx(ctx, "Secret does not exist in Vault, will create new", "path", localSecret.Path)
+			plan.exists = false
+			currentSecret = &vault.Secret{
+				Path: localSecret.Path,
+				Data: make(map[string]string),
+			}
 		}
 	}
+	plan.current = currentSecret
 
-	secretDiff, err := diff.CompareSecrets(currentSecret, localSecret)
+	// A journaled decision from an interrupted run is reused only if neither
+	// the local file nor the secret in Vault changed since it was recorded.
+	// KV v1 has no versions to tell the latter, so nothing is reused there.
+	plan.fingerprint = journal.FileFingerprint(filePath)
+	if localMetadata != nil {
+		plan.fingerprint += "+" + journal.FileFingerprint(sidecar.PathFor(filePath, p.codec.Extension()))
+	}
+	if entry, ok := p.journal.Lookup(localSecret.Path); ok && p.client.KVVersion() == 2 &&
+		entry.LocalFile == plan.fingerprint && entry.Version == currentSecret.Version {
+		logger.InfoCtx(ctx, "Secret already processed in resumed push",
+			"path", localSecret.Path,
+			"status", entry.Status,
+			"version", entry.Version)
+		plan.resumedStatus = entry.Status
+		return plan, nil
+	}
+
+	plan.diff, err = diff.CompareSecrets(currentSecret, localSecret)
 	if err != nil {
-		return false, errors.WrapWithPath(err, "compare_secrets", localSecret.Path)
+		return nil, errors.WrapWithPath(err, "compare_secrets", localSecret.Path)
+	}
+	plan.proposed, plan.changes, plan.writeData = localSecret, plan.diff.Changes, plan.diff.HasDiff
+
+	if localMetadata != nil {
+		currentMetadata, err := p.client.ReadMetadata(ctx, localSecret.Path)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if !vault.IsNotFound(err) {
+				return nil, errors.WrapWithPath(err, "read_metadata", localSecret.Path)
+			}
+			currentMetadata = nil
+		}
+		plan.metadataChanges = localMetadata.Changes(currentMetadata)
 	}
 
-	if !secretDiff.HasDiff {
+	return plan, nil
+}
+
+// processSecret pushes the data of a secret and, when localMetadata is set,
+// the metadata declared in its sidecar.
+func (p *Pusher) processSecret(ctx context.Context, localSecret *vault.Secret, filePath string, localMetadata *sidecar.File) (bool, error) {
+	logger.DebugCtx(ctx, "Processing secret", "path", localSecret.Path)
+	
+	fmt.Printf("\nProcessing: %s\n", localSecret.Path)
+
+	plan, err := p.planSecret(ctx, localSecret, filePath, localMetadata)
+	if err != nil {
+		return false, err
+	}
+	if plan.resumedStatus != "" {
+		fmt.Printf("✓ %s (%s in previous run)\n", localSecret.Path, plan.resumedStatus)
+		return false, nil
+	}
+	if !plan.exists {
+		fmt.Printf("Secret %s does not exist in Vault (will create new)\n", localSecret.Path)
+	}
+	if plan.deleted {
+		fmt.Printf("Latest version %d of %s is deleted (will write a new version)\n", plan.current.Version, localSecret.Path)
+	}
+
+	if !plan.hasChanges() {
 		logger.DebugCtx(ctx, "No changes needed", "path", localSecret.Path)
 		fmt.Printf("✓ No changes needed for %s\n", localSecret.Path)
-		return false, nil
+		return false, p.record(localSecret.Path, journal.StatusUnchanged, plan.current.Version, plan.fingerprint)
 	}
 
 	logger.InfoCtx(ctx, "Changes detected for secret", 
 		"path", localSecret.Path,
-		"has_diff", secretDiff.HasDiff)
+		"has_diff", plan.diff.HasDiff,
+		"metadata_changes", len(plan.metadataChanges))
 	
-	fmt.Println("Changes detected:")
-	diff.PrintDiff(secretDiff)
+	if plan.diff.HasDiff {
+		fmt.Println("Changes detected:")
+		diff.PrintDiff(plan.diff)
+	}
+	if len(plan.metadataChanges) > 0 {
+		fmt.Println("Metadata changes:")
+		diff.PrintKeyChanges(plan.metadataChanges)
+	}
 
 	if p.config.DryRun {
 		logger.InfoCtx(ctx, "Dry run mode - would update secret", "path", localSecret.Path)
@@ -136,28 +385,137 @@ func (p *Pusher) processSecret(ctx context.Context, localSecret *vault.Secret) (
 		return false, nil
 	}
 
-	if !p.config.AutoApprove {
-		if !p.promptForApproval(localSecret.Path) {
+	if p.config.Interactive {
+		fmt.Printf("Reviewing %s key by key\n", localSecret.Path)
+		plan.changes, err = p.reviewKeys(ctx, localSecret.Path, plan.diff.Changes)
+		if err != nil {
+			return false, err
+		}
+		if len(plan.metadataChanges) > 0 && !p.promptForMetadataApproval(ctx, localSecret.Path) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			plan.metadataChanges = nil
+		}
+		// Only the accepted keys change; everything else keeps its value
+		// in Vault.
+		plan.proposed = &vault.Secret{
+			Path: localSecret.Path,
+			Data: diff.Apply(plan.current.Data, plan.changes),
+		}
+		plan.writeData = len(plan.changes) > 0
+		logger.InfoCtx(ctx, "User reviewed secret changes",
+			"path", localSecret.Path,
+			"accepted", len(plan.changes),
+			"proposed", len(plan.diff.Changes))
+		if !plan.hasChanges() {
+			logger.InfoCtx(ctx, "User rejected every change", "path", localSecret.Path)
+			return false, p.skip(plan)
+		}
+	} else if !p.config.AutoApprove {
+		if !p.promptForApproval(ctx, localSecret.Path) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
 			logger.InfoCtx(ctx, "User skipped secret update", "path", localSecret.Path)
-			fmt.Printf("✗ Skipped %s\n", localSecret.Path)
-			return false, nil
+			return false, p.skip(plan)
 		}
 	}
 
-	logger.InfoCtx(ctx, "Writing secret to Vault", "path", localSecret.Path)
-	if err := p.client.WriteSecret(ctx, localSecret); err != nil {
-		return false, errors.WrapWithPath(err, "write_secret", localSecret.Path)
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return true, p.applySecret(ctx, plan)
+}
+
+// applySecret writes an approved plan to Vault and journals it.
+func (p *Pusher) applySecret(ctx context.Context, plan *secretPlan) error {
+	start := time.Now()
+
+	// Once approved, the write is allowed to complete even if an interrupt
+	// arrives meanwhile, so the summary reflects what reached Vault.
+	writeCtx := context.WithoutCancel(ctx)
+	version := plan.current.Version
+	if plan.writeData {
+		logger.InfoCtx(ctx, "Writing secret to Vault", "path", plan.local.Path)
+		var err error
+		version, err = p.writeSecret(writeCtx, plan)
+		if err != nil {
+			return errors.WrapWithPath(err, "write_secret", plan.local.Path)
+		}
+	}
+
+	if len(plan.metadataChanges) > 0 {
+		logger.InfoCtx(ctx, "Writing secret metadata to Vault", "path", plan.local.Path)
+		if err := p.client.WriteMetadata(writeCtx, plan.local.Path, plan.metadata.Update()); err != nil {
+			return errors.WrapWithPath(err, "write_metadata", plan.local.Path)
+		}
 	}
 
 	logger.InfoCtx(ctx, "Successfully updated secret", 
-		"path", localSecret.Path,
+		"path", plan.local.Path,
 		"duration_ms", time.Since(start).Milliseconds())
 	
-	fmt.Printf("✓ Updated %s\n", localSecret.Path)
-	return true, nil
+	fmt.Printf("✓ Updated %s\n", plan.local.Path)
+	return p.record(plan.local.Path, journal.StatusApplied, version, plan.fingerprint)
 }
 
-func (p *Pusher) loadLocalSecret(filePath string) (*vault.Secret, error) {
+// skip reports a declined plan and journals it.
+func (p *Pusher) skip(plan *secretPlan) error {
+	fmt.Printf("✗ Skipped %s\n", plan.local.Path)
+	return p.record(plan.local.Path, journal.StatusSkipped, plan.current.Version, plan.fingerprint)
+}
+
+// writeSecret writes the proposed data of plan over the current secret and
+// returns the version created. With --patch, only the changed keys of an existing
+// secret are sent, falling back to a full write where PATCH is not allowed.
+func (p *Pusher) writeSecret(ctx context.Context, plan *secretPlan) (int64, error) {
+	localSecret, currentSecret := plan.proposed, plan.current
+	// A deleted latest version cannot be patched
+	if p.config.Patch && currentSecret.Version > 0 && !plan.deleted {
+		version, err := p.client.PatchSecret(ctx, localSecret.Path, diff.Patch(plan.changes), currentSecret.Version)
+		if err == nil {
+			return version, nil
+		}
+		if !vault.IsPatchRejected(err) {
+			return 0, err
+		}
+		logger.WarnCtx(ctx, "Patch not allowed, falling back to a full write", "path", localSecret.Path, "error", err)
+		if !p.patchRejected {
+			p.patchRejected = true
+			fmt.Printf("Note: PATCH was not allowed for %s, writing full secrets wherever PATCH is rejected\n", localSecret.Path)
+		}
+	}
+
+	var err error
+	if p.client.KVVersion() == 2 {
+		// Checked against the version the diff was made from, 0 for a new
+		// secret, which also satisfies secrets that have cas_required set.
+		err = p.client.WriteSecretCAS(ctx, localSecret, currentSecret.Version)
+	} else {
+		err = p.client.WriteSecret(ctx, localSecret)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return localSecret.Version, nil
+}
+
+func (p *Pusher) record(secretPath, status string, version int64, fingerprint string) error {
+	err := p.journal.Record(journal.Entry{
+		Path:      secretPath,
+		Status:    status,
+		Version:   version,
+		LocalFile: fingerprint,
+	})
+	if err != nil {
+		return errors.NewWithPath("record_journal", secretPath, err)
+	}
+	return nil
+}
+
+func (p *Pusher) loadLocalSecret(ctx context.Context, filePath string) (*vault.Secret, error) {
 	logger.Debug("Loading local secret file", "file_path", filePath)
 	
 	data, err := os.ReadFile(filePath)
@@ -165,9 +523,9 @@ func (p *Pusher) loadLocalSecret(filePath string) (*vault.Secret, error) {
 		return nil, errors.New("read_file", err).WithContext("file_path", filePath)
 	}
 
-	var secretData map[string]string
-	if err := yaml.Unmarshal(data, &secretData); err != nil {
-		return nil, errors.New("parse_yaml", err).
+	secretData, err := p.codec.Decode(ctx, data)
+	if err != nil {
+		return nil, errors.New("decode_secret", err).
 			WithContext("file_path", filePath).
 			WithContext("file_size", len(data))
 	}
@@ -185,13 +543,55 @@ func (p *Pusher) loadLocalSecret(filePath string) (*vault.Secret, error) {
 	}, nil
 }
 
+// singleSecretFile returns the file written by pull --version when
+// --base-path names a secret rather than a directory: <base path><ext> below
+// the output directory, where a pull of the whole mount writes it. It
+// returns "" when the base path is a directory, or both a secret and a
+// directory, which keeps the usual tree layout.
+func (p *Pusher) singleSecretFile(ctx context.Context) (string, error) {
+	basePath := strings.Trim(p.config.BasePath, "/")
+	if basePath == "" {
+		return "", nil
+	}
+
+	filePath := filepath.Join(p.config.OutputDir, filepath.FromSlash(basePath)+p.codec.Extension())
+	if _, err := os.Stat(filePath); err != nil {
+		return "", nil
+	}
+
+	parent := path.Dir(basePath)
+	if parent == "." {
+		parent = ""
+	}
+	keys, err := p.client.ListSecrets(ctx, parent)
+	if err != nil {
+		if vault.IsNotFound(err) {
+			return "", nil
+		}
+		return "", errors.WrapWithPath(err, "list_secrets", parent)
+	}
+
+	if !slices.Contains(keys, basePath) || slices.Contains(keys, basePath+"/") {
+		return "", nil
+	}
+
+	logger.InfoCtx(ctx, "Base path names a single secret, pushing its file only",
+		"base_path", basePath,
+		"file_path", filePath)
+	return filePath, nil
+}
+
 func (p *Pusher) getVaultPath(filePath string) string {
+	if p.singleFile != "" && filePath == p.singleFile {
+		return strings.Trim(p.config.BasePath, "/")
+	}
+
 	relPath, err := filepath.Rel(p.config.OutputDir, filePath)
 	if err != nil {
 		relPath = filePath
 	}
 
-	vaultPath := strings.TrimSuffix(relPath, ".yaml")
+	vaultPath := strings.TrimSuffix(relPath, p.codec.Extension())
 	vaultPath = strings.ReplaceAll(vaultPath, string(filepath.Separator), "/")
 	
 	if p.config.BasePath != "" {
@@ -205,15 +605,32 @@ func (p *Pusher) getVaultPath(filePath string) string {
 	return vaultPath
 }
 
-func (p *Pusher) promptForApproval(secretPath string) bool {
-	fmt.Printf("Apply changes to %s? [y/N]: ", secretPath)
-	
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
-	if err != nil {
-		return false
-	}
+func (p *Pusher) promptForApproval(ctx context.Context, secretPath string) bool {
+	return prompt.Confirm(ctx, fmt.Sprintf("Apply changes to %s?", secretPath))
+}
+
+func (p *Pusher) promptForMetadataApproval(ctx context.Context, secretPath string) bool {
+	return prompt.Confirm(ctx, fmt.Sprintf("Apply metadata changes to %s?", secretPath))
+}
+
+// printInterruptSummary reports which secrets reached Vault before the push
+// was interrupted and which were left untouched.
+func (p *Pusher) printInterruptSummary(ctx context.Context, applied []string, remaining []*vault.Secret) {
+	logger.WarnCtx(ctx, "Push operation interrupted",
+		"pushed_count", len(applied),
+		"not_applied_count", len(remaining))
 
-	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
+	fmt.Printf("\nPush interrupted: %d secrets applied, %d not applied\n", len(applied), len(remaining))
+	if len(applied) > 0 {
+		fmt.Println("Applied:")
+		for _, path := range applied {
+			fmt.Printf("  ✓ %s\n", path)
+		}
+	}
+	if len(remaining) > 0 {
+		fmt.Println("Not applied:")
+		for _, secret := range remaining {
+			fmt.Printf("  - %s\n", secret.Path)
+		}
+	}
 }
\ No newline at end of file
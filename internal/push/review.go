@@ -0,0 +1,97 @@
+package push
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"vault-sync/internal/diff"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+)
+
+// ErrStopped is returned by Push when the user quits a review. What was
+// applied before has been reported, and the push can be continued with
+// --resume.
+var ErrStopped = stderrors.New("push stopped by user")
+
+// IsStopped reports whether err was caused by the user quitting a review.
+func IsStopped(err error) bool {
+	return stderrors.Is(err, ErrStopped)
+}
+
+// reviewKeys walks the changes of a secret one key at a time and returns
+// the accepted ones, with edited values applied. It returns ErrStopped when
+// the user quits, or when input ends.
+func (p *Pusher) reviewKeys(ctx context.Context, secretPath string, changes []diff.KeyChange) ([]diff.KeyChange, error) {
+	var accepted []diff.KeyChange
+
+	for i := 0; i < len(changes); i++ {
+		change := changes[i]
+		fmt.Printf("\nKey %d of %d:\n", i+1, len(changes))
+		diff.PrintKeyChanges([]diff.KeyChange{change})
+
+		answer, err := p.ask(ctx, "  [a]ccept, [r]eject, [e]dit, accept [A]ll remaining, [q]uit: ")
+		if err != nil {
+			return nil, err
+		}
+
+		switch answer {
+		case "a", "y":
+			accepted = append(accepted, change)
+		case "r", "n", "":
+			logger.DebugCtx(ctx, "User rejected key change", "path", secretPath, "key", change.Key)
+		case "e":
+			value, err := p.ask(ctx, fmt.Sprintf("  New value for %s: ", change.Key))
+			if err != nil {
+				return nil, err
+			}
+			edited, ok := editChange(change, value)
+			if !ok {
+				fmt.Printf("  %s is left unchanged\n", change.Key)
+				continue
+			}
+			accepted = append(accepted, edited)
+		case "A":
+			accepted = append(accepted, changes[i:]...)
+			return accepted, nil
+		case "q":
+			return nil, ErrStopped
+		default:
+			fmt.Println("  Please answer a, r, e, A or q")
+			i--
+		}
+	}
+
+	return accepted, nil
+}
+
+// ask prints question and reads the answer. The end of input counts as
+// quitting, so a push never writes changes that were not reviewed.
+func (p *Pusher) ask(ctx context.Context, question string) (string, error) {
+	fmt.Print(question)
+	answer, err := prompt.ReadLine(ctx)
+	if err != nil {
+		fmt.Println()
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", ErrStopped
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// editChange returns change with value as its new value. Editing a removal
+// keeps the key with the new value instead. It returns false when value
+// leaves the key as it is in Vault.
+func editChange(change diff.KeyChange, value string) (diff.KeyChange, bool) {
+	if change.Type != diff.ChangeAdded && value == change.OldValue {
+		return change, false
+	}
+	if change.Type == diff.ChangeRemoved {
+		change.Type = diff.ChangeModified
+	}
+	change.NewValue = value
+	return change, true
+}
@@ -0,0 +1,203 @@
+package put
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/vault"
+)
+
+// Assignment sets one key of a secret.
+type Assignment struct {
+	Key   string
+	Value string
+}
+
+// ParseAssignments parses key=value arguments. A value of @file is read
+// from that file and a value of - from stdin, both byte for byte; a literal
+// value starting with @ can be written as @@.
+func ParseAssignments(args []string, stdin io.Reader) ([]Assignment, error) {
+	var assignments []Assignment
+	seen := make(map[string]bool)
+	readStdin := false
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid argument %q, expected key=value, key=@file or key=-", arg)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("key %q is given more than once", key)
+		}
+		seen[key] = true
+
+		switch {
+		case value == "-":
+			if readStdin {
+				return nil, fmt.Errorf("only one key can be read from stdin")
+			}
+			readStdin = true
+			raw, err := io.ReadAll(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("read value of %q from stdin: %w", key, err)
+			}
+			value = string(raw)
+		case strings.HasPrefix(value, "@@"):
+			value = value[1:]
+		case strings.HasPrefix(value, "@"):
+			raw, err := os.ReadFile(value[1:])
+			if err != nil {
+				return nil, fmt.Errorf("read value of %q: %w", key, err)
+			}
+			value = string(raw)
+		}
+
+		assignments = append(assignments, Assignment{Key: key, Value: value})
+	}
+	return assignments, nil
+}
+
+// ReadsStdin reports whether any argument reads its value from stdin, which
+// then cannot answer the approval prompt.
+func ReadsStdin(args []string) bool {
+	for _, arg := range args {
+		if _, value, ok := strings.Cut(arg, "="); ok && value == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+type Putter struct {
+	client *vault.Client
+	config *config.Config
+}
+
+func New(client *vault.Client, cfg *config.Config) *Putter {
+	return &Putter{
+		client: client,
+		config: cfg,
+	}
+}
+
+// Put merges assignments into the latest version of a secret, creating it
+// if it does not exist. Keys not assigned keep their value. The key changes
+// are shown with values hidden and written once approved, with
+// check-and-set on the version they were computed from.
+func (p *Putter) Put(ctx context.Context, secretPath string, assignments []Assignment) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Starting put operation",
+		"path", secretPath,
+		"keys", len(assignments),
+		"dry_run", p.config.DryRun)
+
+	current, err := p.client.ReadSecret(ctx, secretPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !vault.IsNotFound(err) {
+			return errors.WrapWithPath(err, "read_secret", secretPath)
+		}
+		// A secret whose latest version is deleted is written with
+		// check-and-set on that version, as cas_required demands
+		current, err = p.client.ReadDeletedSecret(ctx, secretPath)
+		if err != nil {
+			return errors.WrapWithPath(err, "read_metadata", secretPath)
+		}
+		if current != nil {
+			logger.InfoCtx(ctx, "Latest version of secret is deleted, will write a new version",
+				"path", secretPath,
+				"version", current.Version)
+			fmt.Printf("Latest version %d of %s is deleted (will write a new version)\n", current.Version, secretPath)
+		} else {
+			logger.InfoCtx(ctx, "Secret does not exist in Vault, will create new", "path", secretPath)
+			fmt.Printf("Secret %s does not exist in Vault (will create new)\n", secretPath)
+			current = &vault.Secret{
+				Path: secretPath,
+				Data: make(map[string]string),
+			}
+		}
+	}
+
+	proposed := &vault.Secret{
+		Path: secretPath,
+		Data: make(map[string]string, len(current.Data)+len(assignments)),
+	}
+	for k, v := range current.Data {
+		proposed.Data[k] = v
+	}
+	for _, assignment := range assignments {
+		proposed.Data[assignment.Key] = assignment.Value
+	}
+
+	changes := diff.CompareKeys(current.Data, proposed.Data)
+	if len(changes) == 0 {
+		logger.DebugCtx(ctx, "No changes needed", "path", secretPath)
+		fmt.Printf("✓ No changes needed for %s\n", secretPath)
+		return nil
+	}
+
+	fmt.Printf("Changes to %s:\n", secretPath)
+	diff.PrintKeyChanges(diff.MaskValues(changes))
+
+	if p.config.DryRun {
+		logger.InfoCtx(ctx, "Dry run mode - would update secret", "path", secretPath)
+		fmt.Printf("✓ [DRY RUN] Would update %s\n", secretPath)
+		return nil
+	}
+
+	if !p.config.AutoApprove {
+		if !prompt.Confirm(ctx, fmt.Sprintf("Apply changes to %s?", secretPath)) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.InfoCtx(ctx, "User skipped secret update", "path", secretPath)
+			fmt.Printf("✗ Skipped %s\n", secretPath)
+			return nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Once approved, the write is allowed to complete even if an interrupt
+	// arrives meanwhile. It is checked against the version the changes were
+	// computed from, 0 for a new secret, so keys changed concurrently are not
+	// overwritten. KV v1 has no check-and-set.
+	writeCtx := context.WithoutCancel(ctx)
+	if p.client.KVVersion() == 2 {
+		err = p.client.WriteSecretCAS(writeCtx, proposed, current.Version)
+	} else {
+		err = p.client.WriteSecret(writeCtx, proposed)
+	}
+	if err != nil {
+		if vaultErr, ok := err.(*errors.VaultSyncError); ok && vault.IsCASMismatch(err) {
+			err = vaultErr.WithContext("hint", "the secret was changed meanwhile, run put again")
+		}
+		return errors.WrapWithPath(err, "write_secret", secretPath)
+	}
+
+	logger.InfoCtx(ctx, "Put secret",
+		"path", secretPath,
+		"version", proposed.Version,
+		"changes", len(changes),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	if proposed.Version > 0 {
+		fmt.Printf("✓ Updated %s (now version %d)\n", secretPath, proposed.Version)
+	} else {
+		fmt.Printf("✓ Updated %s\n", secretPath)
+	}
+	return nil
+}
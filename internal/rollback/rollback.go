@@ -0,0 +1,231 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/vault"
+)
+
+// Target selects the version to roll back to: either a version number or
+// the version that was current at a point in time.
+type Target struct {
+	Version int64
+	Time    time.Time
+}
+
+func (t Target) String() string {
+	if t.Version > 0 {
+		return fmt.Sprintf("version %d", t.Version)
+	}
+	return t.Time.Format(time.RFC3339)
+}
+
+type Roller struct {
+	client *vault.Client
+	config *config.Config
+}
+
+func New(client *vault.Client, cfg *config.Config) *Roller {
+	return &Roller{
+		client: client,
+		config: cfg,
+	}
+}
+
+// Rollback restores secretPath to target by writing the historical data as
+// a new version. With recursive set, every secret below secretPath is rolled
+// back to the version that was current at target.Time.
+func (r *Roller) Rollback(ctx context.Context, secretPath string, target Target, recursive bool) error {
+	start := time.Now()
+	logger.InfoCtx(ctx, "Starting rollback operation",
+		"path", secretPath,
+		"target", target.String(),
+		"recursive", recursive,
+		"dry_run", r.config.DryRun)
+
+	if !recursive {
+		fmt.Printf("Rolling back %s to %s\n", secretPath, target)
+		_, err := r.rollbackSecret(ctx, secretPath, target, true)
+		return err
+	}
+
+	fmt.Printf("Rolling back secrets under %s to %s\n", secretPath, target)
+
+	var processed, rolledBack int
+	err := r.client.WalkSecrets(ctx, secretPath, func(path string) error {
+		processed++
+		written, err := r.rollbackSecret(ctx, path, target, false)
+		if err != nil {
+			return err
+		}
+		if written {
+			rolledBack++
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			fmt.Printf("\nRollback interrupted: %d secrets rolled back\n", rolledBack)
+		}
+		logger.ErrorCtx(ctx, "Rollback operation failed",
+			"error", err,
+			"processed", processed,
+			"rolled_back", rolledBack,
+			"duration_ms", time.Since(start).Milliseconds())
+		return err
+	}
+
+	logger.InfoCtx(ctx, "Rollback operation completed",
+		"processed", processed,
+		"rolled_back", rolledBack,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	if r.config.DryRun {
+		fmt.Printf("\n[DRY RUN] Processed %d secrets, no changes made\n", processed)
+	} else {
+		fmt.Printf("\nProcessed %d secrets, rolled back %d\n", processed, rolledBack)
+	}
+	return nil
+}
+
+// rollbackSecret rolls back a single secret and reports whether a new version
+// was written. A secret that has no restorable version at the target is an
+// error when strict is set and is skipped with a notice otherwise.
+func (r *Roller) rollbackSecret(ctx context.Context, secretPath string, target Target, strict bool) (bool, error) {
+	start := time.Now()
+	fmt.Printf("\nProcessing: %s\n", secretPath)
+
+	metadata, err := r.client.ReadMetadata(ctx, secretPath)
+	if err != nil {
+		return false, errors.WrapWithPath(err, "read_metadata", secretPath)
+	}
+
+	version, reason := r.resolveTarget(metadata, target)
+	if reason != "" {
+		if strict {
+			return false, errors.NewWithPath("resolve_version", secretPath, fmt.Errorf("%s", reason)).
+				WithContext("target", target.String())
+		}
+		logger.InfoCtx(ctx, "Skipping secret without restorable version",
+			"path", secretPath,
+			"target", target.String(),
+			"reason", reason)
+		fmt.Printf("✗ Skipped %s: %s\n", secretPath, reason)
+		return false, nil
+	}
+
+	historical, err := r.client.ReadSecretVersion(ctx, secretPath, version.Version)
+	if err != nil {
+		return false, errors.WrapWithPath(err, "read_secret_version", secretPath)
+	}
+
+	// The latest version may itself be deleted, in which case the rollback
+	// restores the data on top of an empty secret.
+	current, err := r.client.ReadSecret(ctx, secretPath)
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		current = &vault.Secret{
+			Path: secretPath,
+			Data: make(map[string]string),
+		}
+	}
+
+	proposed := &vault.Secret{
+		Path: secretPath,
+		Data: historical.Data,
+	}
+
+	secretDiff, err := diff.CompareSecrets(current, proposed)
+	if err != nil {
+		return false, errors.WrapWithPath(err, "compare_secrets", secretPath)
+	}
+
+	if !secretDiff.HasDiff {
+		logger.DebugCtx(ctx, "Current data already matches target version",
+			"path", secretPath,
+			"target_version", version.Version)
+		fmt.Printf("✓ %s already matches version %d\n", secretPath, version.Version)
+		return false, nil
+	}
+
+	fmt.Printf("Changes from version %d to version %d:\n", metadata.CurrentVersion, version.Version)
+	diff.PrintDiff(secretDiff)
+
+	if r.config.DryRun {
+		logger.InfoCtx(ctx, "Dry run mode - would roll back secret",
+			"path", secretPath,
+			"target_version", version.Version)
+		fmt.Printf("✓ [DRY RUN] Would roll back %s to version %d\n", secretPath, version.Version)
+		return false, nil
+	}
+
+	if !r.config.AutoApprove {
+		question := fmt.Sprintf("Roll back %s to version %d?", secretPath, version.Version)
+		if !prompt.Confirm(ctx, question) {
+			if ctx.Err() != nil {
+				return false, ctx.Err()
+			}
+			logger.InfoCtx(ctx, "User skipped rollback", "path", secretPath)
+			fmt.Printf("✗ Skipped %s\n", secretPath)
+			return false, nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	// The write is checked against the version the diff was computed from,
+	// so a change made while the user was reviewing is not overwritten.
+	if err := r.client.WriteSecretCAS(context.WithoutCancel(ctx), proposed, metadata.CurrentVersion); err != nil {
+		return false, errors.WrapWithPath(err, "write_secret", secretPath)
+	}
+
+	logger.InfoCtx(ctx, "Rolled back secret",
+		"path", secretPath,
+		"target_version", version.Version,
+		"new_version", proposed.Version,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	fmt.Printf("✓ Rolled back %s to version %d (now version %d)\n", secretPath, version.Version, proposed.Version)
+	return true, nil
+}
+
+// resolveTarget picks the version to restore. The returned reason is
+// non-empty when there is no readable version to restore.
+func (r *Roller) resolveTarget(metadata *vault.SecretMetadata, target Target) (vault.VersionInfo, string) {
+	var version vault.VersionInfo
+	var ok bool
+
+	if target.Version > 0 {
+		version, ok = metadata.Version(target.Version)
+		if !ok {
+			return version, fmt.Sprintf("version %d does not exist or is no longer kept", target.Version)
+		}
+	} else {
+		version, ok = metadata.VersionAt(target.Time)
+		if !ok {
+			return version, fmt.Sprintf("no version kept from before %s", target.Time.Format(time.RFC3339))
+		}
+		if version.Deleted() && !version.DeletionTime.After(target.Time) {
+			return version, fmt.Sprintf("version %d was already deleted at %s", version.Version, target.Time.Format(time.RFC3339))
+		}
+	}
+
+	if version.Destroyed {
+		return version, fmt.Sprintf("version %d has been destroyed", version.Version)
+	}
+	if version.Deleted() {
+		return version, fmt.Sprintf("version %d has been deleted; undelete it first", version.Version)
+	}
+	return version, ""
+}
@@ -0,0 +1,136 @@
+package scope
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/vault"
+)
+
+// Namespaces returns one config per namespace below cfg.VaultNamespace,
+// recursively, each syncing the directory named after the namespace's
+// relative path below the output directory. The directories of child
+// namespaces, with the staging trees and journals their syncs keep next to
+// them, are excluded from their parent's sync. When local is set, as for
+// push, only namespaces that have a local directory are returned.
+func Namespaces(ctx context.Context, client *vault.Client, cfg *config.Config, local bool) ([]*config.Config, error) {
+	namespaces, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoCtx(ctx, "Selected child namespaces", "namespace", cfg.VaultNamespace, "namespaces", namespaces)
+
+	var configs []*config.Config
+	for _, namespace := range namespaces {
+		namespaceCfg := cfg.ForNamespace(namespace)
+		for _, other := range namespaces {
+			if path.Dir(other) == namespace {
+				namespaceCfg.ExcludeDirs = append(namespaceCfg.ExcludeDirs, syncEntries(path.Base(other))...)
+			}
+		}
+		if local && !isDir(namespaceCfg.OutputDir) {
+			fmt.Printf("- No local directory for namespace %s, skipping\n", namespaceCfg.VaultNamespace)
+			continue
+		}
+		configs = append(configs, namespaceCfg)
+	}
+
+	if local {
+		var exclude []string
+		for _, namespace := range namespaces {
+			exclude = append(exclude, syncEntries(namespace)...)
+		}
+		if err := reportUnmatched(cfg.OutputDir, namespaces, exclude, "child namespace"); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// syncEntries returns the entries that the sync of the directory name keeps
+// in its parent directory: the directory itself, the staging tree and
+// backup of a pull, and the pull and push journals.
+func syncEntries(name string) []string {
+	return []string{name, name + ".staging", name + ".old", name + ".pull-journal", name + ".push-journal"}
+}
+
+// Mounts returns one config per mount selected by a multi-mount --kv-mount,
+// each syncing the directory named after the mount below the output
+// directory. "*" selects every KV mount listed in sys/mounts. When local is
+// set, as for push, only mounts that have a local directory are returned,
+// and local directories that match no selected mount are reported.
+func Mounts(ctx context.Context, client *vault.Client, cfg *config.Config, local bool) ([]*config.Config, error) {
+	var mounts []string
+	if cfg.KVMount == config.AllMounts {
+		kvMounts, err := client.ListKVMounts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, mount := range kvMounts {
+			mounts = append(mounts, mount.Path)
+		}
+	} else {
+		mounts = cfg.MountList()
+	}
+
+	logger.InfoCtx(ctx, "Selected KV mounts",
+		"namespace", cfg.VaultNamespace,
+		"kv_mount", cfg.KVMount,
+		"mounts", mounts)
+
+	var configs []*config.Config
+	for _, mount := range mounts {
+		mountCfg := cfg.ForMount(mount)
+		if local && !isDir(mountCfg.OutputDir) {
+			fmt.Printf("- No local directory for mount %s, skipping\n", mount)
+			continue
+		}
+		configs = append(configs, mountCfg)
+	}
+
+	if local {
+		if err := reportUnmatched(cfg.OutputDir, mounts, cfg.ExcludeDirs, "KV mount"); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// reportUnmatched prints the top-level directories of outputDir that do
+// not belong to any of the selected paths or excluded directories, as they
+// are not pushed.
+func reportUnmatched(outputDir string, selected, exclude []string, what string) error {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return errors.New("read_output_dir", err).WithContext("output_dir", outputDir)
+	}
+
+	known := make(map[string]bool)
+	for _, p := range selected {
+		known[strings.SplitN(p, "/", 2)[0]] = true
+	}
+	for _, name := range exclude {
+		known[name] = true
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || known[entry.Name()] {
+			continue
+		}
+		fmt.Printf("- %s matches no selected %s, skipping\n", filepath.Join(outputDir, entry.Name()), what)
+	}
+	return nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,193 @@
+package sidecar
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"vault-sync/internal/diff"
+	"vault-sync/internal/fsutil"
+	"vault-sync/internal/vault"
+)
+
+// Suffix is appended to the secret file name, without its codec extension,
+// to name the sidecar: app/db.yaml.age has its metadata in app/db.meta.yaml.
+const Suffix = ".meta.yaml"
+
+// File is the content of a metadata sidecar. Only the fields present in the
+// file are managed by push; a missing field leaves the value in Vault as it
+// is, while an empty custom_metadata map clears it.
+type File struct {
+	MaxVersions        *int64            `yaml:"max_versions,omitempty"`
+	CasRequired        *bool             `yaml:"cas_required,omitempty"`
+	DeleteVersionAfter *string           `yaml:"delete_version_after,omitempty"`
+	CustomMetadata     map[string]string `yaml:"custom_metadata,omitempty"`
+}
+
+// PathFor returns the sidecar path of the secret file secretFile, written
+// with a codec using extension ext.
+func PathFor(secretFile, ext string) string {
+	return strings.TrimSuffix(secretFile, ext) + Suffix
+}
+
+// IsSidecar reports whether path names a metadata sidecar.
+func IsSidecar(path string) bool {
+	return strings.HasSuffix(path, Suffix)
+}
+
+// FromMetadata returns the sidecar for the metadata of a secret, or nil when
+// there is nothing worth recording. Settings are only recorded when they
+// differ from the mount defaults.
+func FromMetadata(metadata *vault.SecretMetadata) *File {
+	if metadata == nil {
+		return nil
+	}
+
+	f := &File{}
+	empty := true
+	if metadata.MaxVersions != 0 {
+		maxVersions := metadata.MaxVersions
+		f.MaxVersions = &maxVersions
+		empty = false
+	}
+	if metadata.CasRequired {
+		casRequired := true
+		f.CasRequired = &casRequired
+		empty = false
+	}
+	if d, err := parseDuration(metadata.DeleteVersionAfter); err == nil && d != 0 {
+		deleteVersionAfter := metadata.DeleteVersionAfter
+		f.DeleteVersionAfter = &deleteVersionAfter
+		empty = false
+	}
+	if len(metadata.CustomMetadata) > 0 {
+		f.CustomMetadata = metadata.CustomMetadata
+		empty = false
+	}
+
+	if empty {
+		return nil
+	}
+	return f
+}
+
+// Load reads the sidecar at path. It returns nil without error when there
+// is no sidecar.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse metadata sidecar: %w", err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Save writes f to path.
+func Save(path string, f *File) error {
+	data, err := yaml.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return fsutil.WriteFileAtomic(path, data, 0600)
+}
+
+// Changes compares the sections declared in f with the current metadata of
+// a secret. current may be nil for a secret that does not exist yet.
+func (f *File) Changes(current *vault.SecretMetadata) []diff.KeyChange {
+	if f == nil {
+		return nil
+	}
+	if current == nil {
+		current = &vault.SecretMetadata{}
+	}
+
+	currentFields := make(map[string]string)
+	proposedFields := make(map[string]string)
+
+	if f.MaxVersions != nil {
+		currentFields["max_versions"] = strconv.FormatInt(current.MaxVersions, 10)
+		proposedFields["max_versions"] = strconv.FormatInt(*f.MaxVersions, 10)
+	}
+	if f.CasRequired != nil {
+		currentFields["cas_required"] = strconv.FormatBool(current.CasRequired)
+		proposedFields["cas_required"] = strconv.FormatBool(*f.CasRequired)
+	}
+	if f.DeleteVersionAfter != nil {
+		currentFields["delete_version_after"] = current.DeleteVersionAfter
+		proposedFields["delete_version_after"] = *f.DeleteVersionAfter
+		// Vault reports durations normalised, e.g. 720h as 720h0m0s
+		if sameDuration(current.DeleteVersionAfter, *f.DeleteVersionAfter) {
+			proposedFields["delete_version_after"] = current.DeleteVersionAfter
+		}
+	}
+
+	if f.CustomMetadata != nil {
+		for k, v := range current.CustomMetadata {
+			currentFields["custom_metadata."+k] = v
+		}
+		for k, v := range f.CustomMetadata {
+			proposedFields["custom_metadata."+k] = v
+		}
+	}
+
+	return diff.CompareKeys(currentFields, proposedFields)
+}
+
+// Update returns the metadata update that applies the fields of f.
+func (f *File) Update() vault.MetadataUpdate {
+	return vault.MetadataUpdate{
+		CustomMetadata:     f.CustomMetadata,
+		MaxVersions:        f.MaxVersions,
+		CasRequired:        f.CasRequired,
+		DeleteVersionAfter: f.DeleteVersionAfter,
+	}
+}
+
+// Validate checks the settings before anything is sent to Vault.
+func (f *File) Validate() error {
+	if f.MaxVersions != nil && *f.MaxVersions < 0 {
+		return fmt.Errorf("max_versions must not be negative, got %d", *f.MaxVersions)
+	}
+	if f.DeleteVersionAfter != nil {
+		if _, err := parseDuration(*f.DeleteVersionAfter); err != nil {
+			return fmt.Errorf("invalid delete_version_after %q: %w", *f.DeleteVersionAfter, err)
+		}
+	}
+	return nil
+}
+
+func sameDuration(a, b string) bool {
+	da, errA := parseDuration(a)
+	db, errB := parseDuration(b)
+	return errA == nil && errB == nil && da == db
+}
+
+// parseDuration accepts Go durations plus a "d" suffix for days, like Vault.
+// An empty string is the zero duration.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
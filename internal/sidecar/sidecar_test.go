@@ -0,0 +1,232 @@
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"vault-sync/internal/diff"
+	"vault-sync/internal/vault"
+)
+
+func TestPathFor(t *testing.T) {
+	tests := []struct {
+		secretFile string
+		ext        string
+		want       string
+	}{
+		{"app/db.yaml", ".yaml", "app/db.meta.yaml"},
+		{"app/db.yaml.age", ".yaml.age", "app/db.meta.yaml"},
+		{"app/db.json", ".json", "app/db.meta.yaml"},
+	}
+
+	for _, tt := range tests {
+		got := PathFor(tt.secretFile, tt.ext)
+		if got != tt.want {
+			t.Errorf("PathFor(%q, %q) = %q, want %q", tt.secretFile, tt.ext, got, tt.want)
+		}
+		if !IsSidecar(got) {
+			t.Errorf("IsSidecar(%q) = false", got)
+		}
+	}
+	if IsSidecar("app/db.yaml") {
+		t.Error(`IsSidecar("app/db.yaml") = true`)
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	maxVersions := int64(5)
+	casRequired := true
+	deleteVersionAfter := "720h"
+
+	tests := []struct {
+		name     string
+		metadata *vault.SecretMetadata
+		want     *File
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name:     "mount defaults",
+			metadata: &vault.SecretMetadata{DeleteVersionAfter: "0s", CustomMetadata: map[string]string{}},
+		},
+		{
+			name: "settings that differ from the defaults",
+			metadata: &vault.SecretMetadata{
+				MaxVersions:        5,
+				CasRequired:        true,
+				DeleteVersionAfter: "720h",
+				CustomMetadata:     map[string]string{"owner": "team-a"},
+			},
+			want: &File{
+				MaxVersions:        &maxVersions,
+				CasRequired:        &casRequired,
+				DeleteVersionAfter: &deleteVersionAfter,
+				CustomMetadata:     map[string]string{"owner": "team-a"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromMetadata(tt.metadata); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromMetadata() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	current := &vault.SecretMetadata{
+		MaxVersions:        10,
+		CasRequired:        false,
+		DeleteVersionAfter: "720h0m0s",
+		CustomMetadata:     map[string]string{"owner": "team-a", "old": "x"},
+	}
+
+	maxVersions := int64(10)
+	casRequired := true
+	sameDuration := "30d"
+	otherDuration := "1h"
+
+	tests := []struct {
+		name    string
+		file    *File
+		current *vault.SecretMetadata
+		want    []diff.KeyChange
+	}{
+		{
+			name:    "nil sidecar",
+			current: current,
+		},
+		{
+			name:    "unchanged fields",
+			file:    &File{MaxVersions: &maxVersions, DeleteVersionAfter: &sameDuration},
+			current: current,
+		},
+		{
+			// Fields missing from the sidecar are not managed
+			name:    "changed field",
+			file:    &File{CasRequired: &casRequired},
+			current: current,
+			want: []diff.KeyChange{
+				{Key: "cas_required", Type: diff.ChangeModified, OldValue: "false", NewValue: "true"},
+			},
+		},
+		{
+			name:    "changed duration",
+			file:    &File{DeleteVersionAfter: &otherDuration},
+			current: current,
+			want: []diff.KeyChange{
+				{Key: "delete_version_after", Type: diff.ChangeModified, OldValue: "720h0m0s", NewValue: "1h"},
+			},
+		},
+		{
+			name:    "custom metadata replaced",
+			file:    &File{CustomMetadata: map[string]string{"owner": "team-b"}},
+			current: current,
+			want: []diff.KeyChange{
+				{Key: "custom_metadata.old", Type: diff.ChangeRemoved, OldValue: "x"},
+				{Key: "custom_metadata.owner", Type: diff.ChangeModified, OldValue: "team-a", NewValue: "team-b"},
+			},
+		},
+		{
+			name: "secret that does not exist yet",
+			file: &File{CasRequired: &casRequired},
+			want: []diff.KeyChange{
+				{Key: "cas_required", Type: diff.ChangeModified, OldValue: "false", NewValue: "true"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.Changes(tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Changes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *File
+		wantErr string
+	}{
+		{
+			name: "no sidecar",
+		},
+		{
+			name:    "custom metadata only",
+			content: "custom_metadata:\n  owner: team-a\n",
+			want:    &File{CustomMetadata: map[string]string{"owner": "team-a"}},
+		},
+		{
+			name:    "negative max_versions",
+			content: "max_versions: -1\n",
+			wantErr: "max_versions must not be negative",
+		},
+		{
+			name:    "invalid duration",
+			content: "delete_version_after: soon\n",
+			wantErr: "invalid delete_version_after",
+		},
+		{
+			name:    "not YAML",
+			content: "custom_metadata: [\n",
+			wantErr: "parse metadata sidecar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "db.meta.yaml")
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	maxVersions := int64(3)
+	casRequired := false
+	f := &File{
+		MaxVersions:    &maxVersions,
+		CasRequired:    &casRequired,
+		CustomMetadata: map[string]string{"owner": "team-a"},
+	}
+
+	path := filepath.Join(t.TempDir(), "db.meta.yaml")
+	if err := Save(path, f); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, f) {
+		t.Errorf("Load(Save()) = %+v, want %+v", got, f)
+	}
+}
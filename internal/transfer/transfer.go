@@ -0,0 +1,383 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"vault-sync/internal/config"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+	"vault-sync/internal/prompt"
+	"vault-sync/internal/vault"
+)
+
+// ConflictPolicy decides what happens when a destination secret exists.
+type ConflictPolicy string
+
+const (
+	ConflictFail      ConflictPolicy = "fail"
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(s); policy {
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q (expected fail, skip or overwrite)", s)
+	}
+}
+
+type Options struct {
+	Recursive bool
+	// Move deletes each source secret, with its metadata and all versions,
+	// once it has been copied.
+	Move bool
+	// History replays every readable version instead of only the current one.
+	History    bool
+	OnConflict ConflictPolicy
+}
+
+// item is the planned copy of a single secret.
+type item struct {
+	srcPath  string
+	dstPath  string
+	metadata *vault.SecretMetadata
+	versions []int64
+	// dstVersion is the current version at the destination, 0 if the
+	// destination does not exist.
+	dstVersion int64
+	dstExists  bool
+}
+
+// Transfer copies secrets between two KV locations, which may be on
+// different mounts or in different namespaces. KV v1 mounts have no versions
+// or metadata, so only the current data is copied from or to them.
+type Transfer struct {
+	src       *vault.Client
+	srcConfig *config.Config
+	dst       *vault.Client
+	dstConfig *config.Config
+}
+
+func New(src *vault.Client, srcCfg *config.Config, dst *vault.Client, dstCfg *config.Config) *Transfer {
+	return &Transfer{
+		src:       src,
+		srcConfig: srcCfg,
+		dst:       dst,
+		dstConfig: dstCfg,
+	}
+}
+
+// Copy copies srcPath to dstPath, or with opts.Recursive every secret below
+// srcPath to the same relative path below dstPath. All secrets are planned
+// and checked for conflicts before anything is written.
+func (t *Transfer) Copy(ctx context.Context, srcPath, dstPath string, opts Options) error {
+	start := time.Now()
+	operation := "copy"
+	if opts.Move {
+		operation = "move"
+	}
+
+	logger.InfoCtx(ctx, "Starting "+operation+" operation",
+		"source", t.srcLabel(srcPath),
+		"destination", t.dstLabel(dstPath),
+		"recursive", opts.Recursive,
+		"history", opts.History,
+		"on_conflict", string(opts.OnConflict),
+		"dry_run", t.srcConfig.DryRun)
+
+	srcPath = strings.Trim(srcPath, "/")
+	dstPath = strings.Trim(dstPath, "/")
+
+	if err := t.checkOverlap(srcPath, dstPath, opts.Recursive); err != nil {
+		return errors.New("check_"+operation+"_paths", err).
+			WithContext("source", t.srcLabel(srcPath)).
+			WithContext("destination", t.dstLabel(dstPath))
+	}
+
+	items, err := t.plan(ctx, srcPath, dstPath, opts)
+	if err != nil {
+		return err
+	}
+
+	var conflicts, pending []item
+	for _, it := range items {
+		if it.dstExists {
+			conflicts = append(conflicts, it)
+			if opts.OnConflict == ConflictSkip {
+				fmt.Printf("- %s already exists, skipping\n", t.dstLabel(it.dstPath))
+				continue
+			}
+		}
+		pending = append(pending, it)
+	}
+
+	if len(conflicts) > 0 && opts.OnConflict == ConflictFail {
+		fmt.Println("Destination secrets already exist:")
+		for _, it := range conflicts {
+			fmt.Printf("  %s\n", t.dstLabel(it.dstPath))
+		}
+		return errors.New(operation+"_conflict",
+			fmt.Errorf("%d destination secrets already exist; use --on-conflict skip or overwrite", len(conflicts))).
+			WithContext("destination", t.dstLabel(dstPath))
+	}
+
+	if len(pending) == 0 {
+		fmt.Printf("Nothing to %s\n", operation)
+		return nil
+	}
+
+	fmt.Printf("\nWill %s:\n", operation)
+	for _, it := range pending {
+		fmt.Printf("  %s -> %s (%s)\n", t.srcLabel(it.srcPath), t.dstLabel(it.dstPath), describe(it))
+	}
+
+	if t.srcConfig.DryRun {
+		logger.InfoCtx(ctx, "Dry run mode - would "+operation+" secrets", "count", len(pending))
+		fmt.Printf("\n[DRY RUN] Would %s %d secrets\n", operation, len(pending))
+		return nil
+	}
+
+	if !t.srcConfig.AutoApprove {
+		question := fmt.Sprintf("\n%s %d secrets?", strings.ToUpper(operation[:1])+operation[1:], len(pending))
+		if opts.Move {
+			question = fmt.Sprintf("\nMove %d secrets? The sources are deleted with their metadata after copying.", len(pending))
+			if !opts.History {
+				question += " Their version history is not kept without --history."
+			}
+		}
+		if !prompt.Confirm(ctx, question) {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.InfoCtx(ctx, "User cancelled "+operation+" operation")
+			fmt.Println("✗ Cancelled, nothing was changed")
+			return nil
+		}
+	}
+
+	done := 0
+	for _, it := range pending {
+		if err := ctx.Err(); err != nil {
+			fmt.Printf("\nInterrupted: %d of %d secrets done\n", done, len(pending))
+			return err
+		}
+		// Once started, a secret is finished so it is never left half moved.
+		if err := t.copyItem(context.WithoutCancel(ctx), it, opts); err != nil {
+			fmt.Printf("✗ Failed to %s %s\n", operation, t.srcLabel(it.srcPath))
+			return err
+		}
+		done++
+		verb := "Copied"
+		if opts.Move {
+			verb = "Moved"
+		}
+		fmt.Printf("✓ %s %s -> %s\n", verb, t.srcLabel(it.srcPath), t.dstLabel(it.dstPath))
+	}
+
+	logger.InfoCtx(ctx, "Completed "+operation+" operation",
+		"count", done,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	if opts.Move {
+		fmt.Printf("\nMoved %d secrets\n", done)
+	} else {
+		fmt.Printf("\nCopied %d secrets\n", done)
+	}
+	return nil
+}
+
+func (t *Transfer) plan(ctx context.Context, srcPath, dstPath string, opts Options) ([]item, error) {
+	var items []item
+
+	add := func(path string) error {
+		target := dstPath
+		if opts.Recursive {
+			target = dstPath + "/" + strings.TrimPrefix(strings.TrimPrefix(path, srcPath), "/")
+			target = strings.TrimPrefix(target, "/")
+		}
+		it, err := t.planItem(ctx, path, target, opts)
+		if err != nil {
+			return err
+		}
+		if it != nil {
+			items = append(items, *it)
+		}
+		return nil
+	}
+
+	if !opts.Recursive {
+		return items, add(srcPath)
+	}
+	return items, t.src.WalkSecrets(ctx, srcPath, add)
+}
+
+// planItem reads what is needed to copy srcPath. It returns nil when the
+// source has no readable data to copy.
+func (t *Transfer) planItem(ctx context.Context, srcPath, dstPath string, opts Options) (*item, error) {
+	var versions []int64
+	metadata, err := t.src.ReadMetadata(ctx, srcPath)
+	switch {
+	case err == nil:
+		if opts.History {
+			for _, v := range metadata.Versions {
+				if v.Readable() {
+					versions = append(versions, v.Version)
+				}
+			}
+		} else if current, ok := metadata.Version(metadata.CurrentVersion); ok && current.Readable() {
+			versions = []int64{current.Version}
+		}
+	case vault.IsUnsupported(err):
+		// KV v1 keeps only the current data, which version 0 reads
+		metadata = &vault.SecretMetadata{Path: srcPath}
+		versions = []int64{0}
+	default:
+		return nil, errors.WrapWithPath(err, "read_source_metadata", srcPath)
+	}
+	if len(versions) == 0 {
+		fmt.Printf("- %s has no readable version, skipping\n", t.srcLabel(srcPath))
+		return nil, nil
+	}
+
+	it := &item{
+		srcPath:  srcPath,
+		dstPath:  dstPath,
+		metadata: metadata,
+		versions: versions,
+	}
+
+	dstMetadata, err := t.dst.ReadMetadata(ctx, dstPath)
+	switch {
+	case err == nil:
+		it.dstExists = true
+		it.dstVersion = dstMetadata.CurrentVersion
+	case vault.IsUnsupported(err):
+		// KV v1 keeps no metadata, so the secret exists if it can be read
+		_, err := t.dst.ReadSecret(ctx, dstPath)
+		switch {
+		case err == nil:
+			it.dstExists = true
+		case !vault.IsNotFound(err):
+			return nil, errors.WrapWithPath(err, "read_destination_secret", dstPath)
+		}
+		if len(metadata.CustomMetadata) > 0 {
+			fmt.Printf("Note: the destination is KV v1, %d custom metadata keys of %s are not copied\n",
+				len(metadata.CustomMetadata), t.srcLabel(srcPath))
+		}
+	case !vault.IsNotFound(err):
+		return nil, errors.WrapWithPath(err, "read_destination_metadata", dstPath)
+	}
+
+	return it, nil
+}
+
+func (t *Transfer) copyItem(ctx context.Context, it item, opts Options) error {
+	start := time.Now()
+	dstVersion := it.dstVersion
+
+	for _, version := range it.versions {
+		secret, err := t.src.ReadSecretVersion(ctx, it.srcPath, version)
+		if err != nil {
+			return errors.WrapWithPath(err, "read_source_secret", it.srcPath)
+		}
+
+		copied := &vault.Secret{
+			Path: it.dstPath,
+			Data: secret.Data,
+		}
+		// Checked against the destination as planned, so a secret created or
+		// changed there since is not overwritten. KV v1 has no check-and-set.
+		if t.dst.KVVersion() == 1 {
+			err = t.dst.WriteSecret(ctx, copied)
+		} else {
+			err = t.dst.WriteSecretCAS(ctx, copied, dstVersion)
+		}
+		if err != nil {
+			return errors.WrapWithPath(err, "write_destination_secret", it.dstPath)
+		}
+		dstVersion = copied.Version
+	}
+
+	if t.dst.KVVersion() == 2 && (len(it.metadata.CustomMetadata) > 0 || it.dstExists) {
+		if err := t.dst.WriteCustomMetadata(ctx, it.dstPath, it.metadata.CustomMetadata); err != nil {
+			return errors.WrapWithPath(err, "write_destination_metadata", it.dstPath)
+		}
+	}
+
+	if opts.Move {
+		// A KV v1 secret has no metadata; deleting it removes it for good
+		deleteSource := t.src.DeleteMetadata
+		if t.src.KVVersion() == 1 {
+			deleteSource = t.src.DeleteSecret
+		}
+		if err := deleteSource(ctx, it.srcPath); err != nil {
+			return errors.WrapWithPath(err, "delete_source", it.srcPath)
+		}
+	}
+
+	logger.InfoCtx(ctx, "Copied secret",
+		"source", t.srcLabel(it.srcPath),
+		"destination", t.dstLabel(it.dstPath),
+		"versions", len(it.versions),
+		"destination_version", dstVersion,
+		"moved", opts.Move,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// checkOverlap rejects copying a path onto itself or a subtree into itself.
+func (t *Transfer) checkOverlap(srcPath, dstPath string, recursive bool) error {
+	if t.srcConfig.VaultAddr != t.dstConfig.VaultAddr ||
+		t.srcConfig.VaultNamespace != t.dstConfig.VaultNamespace ||
+		t.srcConfig.KVMount != t.dstConfig.KVMount {
+		return nil
+	}
+	if srcPath == dstPath {
+		return fmt.Errorf("source and destination are the same")
+	}
+	if recursive && (srcPath == "" || strings.HasPrefix(dstPath+"/", srcPath+"/")) {
+		return fmt.Errorf("destination is inside the source tree")
+	}
+	return nil
+}
+
+func (t *Transfer) srcLabel(path string) string {
+	return label(t.srcConfig, path)
+}
+
+func (t *Transfer) dstLabel(path string) string {
+	return label(t.dstConfig, path)
+}
+
+// label formats a location as [namespace:]mount/path.
+func label(cfg *config.Config, path string) string {
+	l := strings.Trim(cfg.KVMount, "/") + "/" + strings.TrimPrefix(path, "/")
+	if cfg.VaultNamespace != "" {
+		l = strings.Trim(cfg.VaultNamespace, "/") + ":" + l
+	}
+	return l
+}
+
+func describe(it item) string {
+	parts := []string{fmt.Sprintf("version %d", it.versions[0])}
+	switch {
+	case len(it.versions) > 1:
+		parts = []string{fmt.Sprintf("%d versions", len(it.versions))}
+	case it.versions[0] == 0:
+		// A KV v1 source has no versions
+		parts = []string{"current data"}
+	}
+	if len(it.metadata.CustomMetadata) > 0 {
+		parts = append(parts, "custom metadata")
+	}
+	if it.dstExists {
+		parts = append(parts, "overwrite")
+	}
+	return strings.Join(parts, ", ")
+}
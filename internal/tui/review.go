@@ -0,0 +1,378 @@
+package tui
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"vault-sync/internal/diff"
+)
+
+// ErrCancelled is returned by Review when the user quits without
+// confirming.
+var ErrCancelled = stderrors.New("review cancelled")
+
+// Item is one changed secret shown for review.
+type Item struct {
+	Path string
+	// New is set when the secret does not exist in Vault yet.
+	New      bool
+	Diff     *diff.SecretDiff
+	Metadata []diff.KeyChange
+}
+
+// IsTerminal reports whether stdin and stdout are both terminals, which
+// Review needs.
+func IsTerminal() bool {
+	for _, f := range []*os.File{os.Stdin, os.Stdout} {
+		info, err := f.Stat()
+		if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Review shows items full-screen: a list of secrets with status badges to
+// select from, the changes of the highlighted secret with values hidden
+// until toggled, and a final confirm screen. It returns which items were
+// approved, in the order given, or ErrCancelled when the user quits.
+func Review(ctx context.Context, title string, items []Item) ([]bool, error) {
+	m := &model{
+		title:    title,
+		items:    items,
+		selected: make([]bool, len(items)),
+		masked:   true,
+	}
+	for i := range m.selected {
+		m.selected[i] = true
+	}
+
+	program := tea.NewProgram(m, tea.WithContext(ctx), tea.WithAltScreen())
+	if _, err := program.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if !m.confirmed {
+		return nil, ErrCancelled
+	}
+	return m.selected, nil
+}
+
+var (
+	titleStyle    = lipgloss.NewStyle().Bold(true)
+	helpStyle     = lipgloss.NewStyle().Faint(true)
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	modifiedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	removedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	metadataStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("4"))
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder())
+)
+
+type model struct {
+	title    string
+	items    []Item
+	selected []bool
+	// cursor is the highlighted item and offset the first one shown.
+	cursor int
+	offset int
+	// scroll is the first line of the changes pane shown.
+	scroll    int
+	masked    bool
+	confirm   bool
+	confirmed bool
+	width     int
+	height    int
+}
+
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		if m.confirm {
+			return m, m.updateConfirm(msg.String())
+		}
+		return m, m.updateList(msg.String())
+	}
+	return m, nil
+}
+
+func (m *model) updateList(key string) tea.Cmd {
+	switch key {
+	case "ctrl+c", "q", "esc":
+		return tea.Quit
+	case "up", "k":
+		m.moveTo(m.cursor - 1)
+	case "down", "j":
+		m.moveTo(m.cursor + 1)
+	case "home", "g":
+		m.moveTo(0)
+	case "end", "G":
+		m.moveTo(len(m.items) - 1)
+	case " ", "x":
+		m.selected[m.cursor] = !m.selected[m.cursor]
+	case "a":
+		m.selectAll(true)
+	case "n":
+		m.selectAll(false)
+	case "m":
+		m.masked = !m.masked
+	case "pgdown", "ctrl+d":
+		m.scroll += m.paneHeight() / 2
+	case "pgup", "ctrl+u":
+		m.scroll = max(m.scroll-m.paneHeight()/2, 0)
+	case "enter":
+		m.confirm = true
+	}
+	return nil
+}
+
+func (m *model) updateConfirm(key string) tea.Cmd {
+	switch key {
+	case "ctrl+c", "q":
+		return tea.Quit
+	case "y":
+		m.confirmed = true
+		return tea.Quit
+	case "n", "esc":
+		m.confirm = false
+	}
+	return nil
+}
+
+func (m *model) moveTo(i int) {
+	if i < 0 || i >= len(m.items) {
+		return
+	}
+	m.cursor = i
+	m.scroll = 0
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if rows := m.paneHeight(); m.cursor >= m.offset+rows {
+		m.offset = m.cursor - rows + 1
+	}
+}
+
+func (m *model) selectAll(selected bool) {
+	for i := range m.selected {
+		m.selected[i] = selected
+	}
+}
+
+func (m *model) selectedCount() int {
+	count := 0
+	for _, selected := range m.selected {
+		if selected {
+			count++
+		}
+	}
+	return count
+}
+
+// paneHeight is the number of lines inside the panes, leaving room for the
+// header, the help line and the borders.
+func (m *model) paneHeight() int {
+	return max(m.height-4, 1)
+}
+
+func (m *model) View() string {
+	if m.width == 0 {
+		return ""
+	}
+	if m.confirm {
+		return m.confirmView()
+	}
+
+	values := "values hidden"
+	if !m.masked {
+		values = "values shown"
+	}
+	header := fmt.Sprintf("%s  %d of %d selected · %s",
+		titleStyle.Render(m.title), m.selectedCount(), len(m.items), values)
+
+	listWidth := min(m.listWidth(), m.width/2)
+	diffWidth := max(m.width-listWidth-4, 10)
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		m.pane(m.listLines(), listWidth),
+		m.pane(m.diffLines(), diffWidth))
+
+	help := helpStyle.Render(truncate("↑/↓ move · space select · a all · n none · m show/hide values · pgup/pgdn scroll · enter confirm · q quit", m.width))
+	return lipgloss.JoinVertical(lipgloss.Left, truncate(header, m.width), panes, help)
+}
+
+// pane renders lines in a bordered box of the given inner width, cut to
+// the pane height.
+func (m *model) pane(lines []string, width int) string {
+	height := m.paneHeight()
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return paneStyle.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+func (m *model) listWidth() int {
+	width := 0
+	for _, item := range m.items {
+		width = max(width, len(item.Path))
+	}
+	return width + len("> [x] META ") + len(" +00 ~00 -00")
+}
+
+func (m *model) listLines() []string {
+	var lines []string
+	for i := m.offset; i < len(m.items) && i < m.offset+m.paneHeight(); i++ {
+		item := m.items[i]
+		check := "[ ]"
+		if m.selected[i] {
+			check = "[x]"
+		}
+		pointer := "  "
+		path := item.Path
+		if i == m.cursor {
+			pointer = "> "
+			path = cursorStyle.Render(path)
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %s %s", pointer, check, badge(item), path, counts(item)))
+	}
+	return lines
+}
+
+func (m *model) diffLines() []string {
+	if len(m.items) == 0 {
+		return nil
+	}
+	item := m.items[m.cursor]
+
+	lines := []string{titleStyle.Render(item.Path)}
+	if item.New {
+		lines = append(lines, "Does not exist in Vault yet, will be created")
+	}
+	if len(item.Diff.Changes) > 0 {
+		changes := item.Diff.Changes
+		if m.masked {
+			changes = diff.MaskValues(changes)
+		}
+		lines = append(lines, "", "Changes:")
+		lines = append(lines, changeLines(changes)...)
+	}
+	if len(item.Metadata) > 0 {
+		lines = append(lines, "", "Metadata changes:")
+		lines = append(lines, changeLines(item.Metadata)...)
+	}
+
+	// Values may span several lines
+	lines = strings.Split(strings.Join(lines, "\n"), "\n")
+	m.scroll = min(m.scroll, max(len(lines)-m.paneHeight(), 0))
+	return lines[m.scroll:]
+}
+
+func (m *model) confirmView() string {
+	count := m.selectedCount()
+	lines := []string{
+		titleStyle.Render(fmt.Sprintf("Apply changes to %d of %d secrets?", count, len(m.items))),
+		"",
+	}
+
+	var skipped []string
+	for i, item := range m.items {
+		if m.selected[i] {
+			lines = append(lines, fmt.Sprintf("  ✓ %s %s", badge(item), item.Path))
+		} else {
+			skipped = append(skipped, fmt.Sprintf("  - %s", item.Path))
+		}
+	}
+	if count == 0 {
+		lines = append(lines, "  Nothing is selected; every secret will be skipped")
+	}
+	if len(skipped) > 0 {
+		lines = append(lines, "", "Skipped:")
+		lines = append(lines, skipped...)
+	}
+
+	// Keep the question and help visible on small terminals
+	if limit := max(m.height-2, 3); len(lines) > limit {
+		more := len(lines) - limit + 1
+		lines = append(lines[:limit-1], fmt.Sprintf("  … and %d more", more))
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, m.width)
+	}
+
+	lines = append(lines, "", helpStyle.Render("y apply · n/esc go back · q quit without applying"))
+	return strings.Join(lines, "\n")
+}
+
+// badge returns the status shown next to a secret: NEW for secrets that
+// will be created, CHG for changed data and META for metadata-only changes.
+func badge(item Item) string {
+	switch {
+	case item.New:
+		return addedStyle.Render("NEW ")
+	case len(item.Diff.Changes) > 0:
+		return modifiedStyle.Render("CHG ")
+	default:
+		return metadataStyle.Render("META")
+	}
+}
+
+func counts(item Item) string {
+	var added, modified, removed int
+	for _, change := range item.Diff.Changes {
+		switch change.Type {
+		case diff.ChangeAdded:
+			added++
+		case diff.ChangeModified:
+			modified++
+		case diff.ChangeRemoved:
+			removed++
+		}
+	}
+
+	var parts []string
+	if added > 0 {
+		parts = append(parts, addedStyle.Render(fmt.Sprintf("+%d", added)))
+	}
+	if modified > 0 {
+		parts = append(parts, modifiedStyle.Render(fmt.Sprintf("~%d", modified)))
+	}
+	if removed > 0 {
+		parts = append(parts, removedStyle.Render(fmt.Sprintf("-%d", removed)))
+	}
+	return strings.Join(parts, " ")
+}
+
+func changeLines(changes []diff.KeyChange) []string {
+	var lines []string
+	for _, change := range changes {
+		switch change.Type {
+		case diff.ChangeAdded:
+			lines = append(lines, addedStyle.Render(fmt.Sprintf("  + %s: %s", change.Key, change.NewValue)))
+		case diff.ChangeModified:
+			lines = append(lines, modifiedStyle.Render(fmt.Sprintf("  ~ %s: %s -> %s", change.Key, change.OldValue, change.NewValue)))
+		case diff.ChangeRemoved:
+			lines = append(lines, removedStyle.Render(fmt.Sprintf("  - %s: %s", change.Key, change.OldValue)))
+		}
+	}
+	return lines
+}
+
+// truncate cuts s to width terminal cells, keeping ANSI styles intact.
+func truncate(s string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
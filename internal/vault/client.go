@@ -2,9 +2,12 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"net/http"
-	"path"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,11 +21,16 @@ import (
 type Client struct {
 	client *vault.Client
 	config *config.Config
+	// kvVersion is the configured KV version until DetectMount finds it.
+	kvVersion int
+	mount     *MountInfo
 }
 
 type Secret struct {
 	Path string            `yaml:"path"`
 	Data map[string]string `yaml:"data"`
+	// Version is the KV v2 version that was read or created, 0 if unknown.
+	Version int64 `yaml:"-"`
 }
 
 func NewClient(cfg *config.Config) (*Client, error) {
@@ -34,7 +42,7 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	
 	client, err := vault.New(
 		vault.WithAddress(cfg.VaultAddr),
-		vault.WithRequestTimeout(30),
+		vault.WithRequestTimeout(30*time.Second),
 	)
 	if err != nil {
 		return nil, errors.New("create_vault_client", err).WithContext("vault_addr", cfg.VaultAddr)
@@ -49,10 +57,16 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		logger.Debug("Set Vault namespace", "namespace", cfg.VaultNamespace)
 	}
 
+	kvVersion := cfg.KVVersion
+	if kvVersion == 0 {
+		kvVersion = 2
+	}
+
 	logger.Info("Successfully created Vault client")
 	return &Client{
-		client: client,
-		config: cfg,
+		client:    client,
+		config:    cfg,
+		kvVersion: kvVersion,
 	}, nil
 }
 
@@ -81,7 +95,12 @@ func (c *Client) ListSecrets(ctx context.Context, path string) ([]string, error)
 		logger.DebugCtx(ctx, "Set Vault namespace for API call", "namespace", c.config.VaultNamespace)
 	}
 
-	resp, err := c.client.Secrets.KvV2List(ctx, listPath, vault.WithMountPath(c.config.KVMount))
+	list := c.client.Secrets.KvV2List
+	if c.KVVersion() == 1 {
+		list = c.client.Secrets.KvV1List
+	}
+
+	resp, err := list(ctx, listPath, vault.WithMountPath(c.config.KVMount))
 	if err != nil {
 		vaultErr := errors.NewWithPath("list_secrets", listPath, err).
 			WithContext("mount", c.config.KVMount).
@@ -143,16 +162,57 @@ func (c *Client) ListSecrets(ctx context.Context, path string) ([]string, error)
 }
 
 func (c *Client) ReadSecret(ctx context.Context, secretPath string) (*Secret, error) {
+	return c.readSecret(ctx, secretPath, 0)
+}
+
+// ReadSecretVersion reads a specific KV v2 version of a secret. Reading a
+// deleted or destroyed version fails with a 404 like a missing secret.
+func (c *Client) ReadSecretVersion(ctx context.Context, secretPath string, version int64) (*Secret, error) {
+	return c.readSecret(ctx, secretPath, version)
+}
+
+func (c *Client) readSecret(ctx context.Context, secretPath string, version int64) (*Secret, error) {
 	start := time.Now()
 	readPath := strings.TrimPrefix(secretPath, "/")
 	
-	logger.DebugCtx(ctx, "Reading secret", "path", secretPath, "mount", c.config.KVMount)
+	logger.DebugCtx(ctx, "Reading secret", "path", secretPath, "mount", c.config.KVMount, "version", version)
+
+	if version > 0 && c.KVVersion() == 1 {
+		return nil, c.unsupportedOnKVv1("read_secret", secretPath, "reading a specific version")
+	}
 
-	resp, err := c.client.Secrets.KvV2Read(ctx, readPath, vault.WithMountPath(c.config.KVMount))
+	options := []vault.RequestOption{vault.WithMountPath(c.config.KVMount)}
+	if version > 0 {
+		options = append(options, vault.WithQueryParameters(url.Values{
+			"version": []string{strconv.FormatInt(version, 10)},
+		}))
+	}
+
+	// KV v1 returns the data itself and has no version metadata
+	var rawData map[string]interface{}
+	var readVersion int64
+	var err error
+	if c.KVVersion() == 1 {
+		var resp *vault.Response[map[string]interface{}]
+		resp, err = c.client.Secrets.KvV1Read(ctx, readPath, options...)
+		if resp != nil {
+			rawData = resp.Data
+		}
+	} else {
+		var resp *vault.Response[schema.KvV2ReadResponse]
+		resp, err = c.client.Secrets.KvV2Read(ctx, readPath, options...)
+		if resp != nil {
+			rawData = resp.Data.Data
+			readVersion = toInt64(resp.Data.Metadata["version"])
+		}
+	}
 	if err != nil {
 		vaultErr := errors.NewWithPath("read_secret", secretPath, err).
 			WithContext("mount", c.config.KVMount).
 			WithContext("duration_ms", time.Since(start).Milliseconds())
+		if version > 0 {
+			vaultErr = vaultErr.WithContext("version", version)
+		}
 		
 		if responseErr, ok := err.(*vault.ResponseError); ok {
 			vaultErr = vaultErr.
@@ -169,28 +229,46 @@ func (c *Client) ReadSecret(ctx context.Context, secretPath string) (*Secret, er
 	}
 
 	data := make(map[string]string)
-	if resp != nil && resp.Data.Data != nil {
-		for k, v := range resp.Data.Data {
-			if str, ok := v.(string); ok {
-				data[k] = str
-			} else {
-				data[k] = fmt.Sprintf("%v", v)
-			}
+	for k, v := range rawData {
+		if str, ok := v.(string); ok {
+			data[k] = str
+		} else {
+			data[k] = fmt.Sprintf("%v", v)
 		}
 	}
 
 	logger.DebugCtx(ctx, "Read secret successfully", 
 		"path", secretPath,
+		"version", readVersion,
 		"key_count", len(data),
 		"duration_ms", time.Since(start).Milliseconds())
 
 	return &Secret{
-		Path: secretPath,
-		Data: data,
+		Path:    secretPath,
+		Data:    data,
+		Version: readVersion,
 	}, nil
 }
 
+// WriteSecret writes secret.Data as a new version. On success secret.Version
+// is updated to the version that was created. On KV v1 the data is replaced
+// in place and secret.Version stays 0.
 func (c *Client) WriteSecret(ctx context.Context, secret *Secret) error {
+	return c.writeSecret(ctx, secret, nil)
+}
+
+// WriteSecretCAS writes secret.Data only if the current version of the secret
+// is still cas, so a concurrent change is not silently overwritten. A cas of
+// 0 only allows the write if the secret does not exist yet. KV v1 has no
+// check-and-set.
+func (c *Client) WriteSecretCAS(ctx context.Context, secret *Secret, cas int64) error {
+	if c.KVVersion() == 1 {
+		return c.unsupportedOnKVv1("write_secret", secret.Path, "check-and-set writes")
+	}
+	return c.writeSecret(ctx, secret, map[string]interface{}{"cas": cas})
+}
+
+func (c *Client) writeSecret(ctx context.Context, secret *Secret, options map[string]interface{}) error {
 	start := time.Now()
 	writePath := strings.TrimPrefix(secret.Path, "/")
 	
@@ -204,16 +282,30 @@ func (c *Client) WriteSecret(ctx context.Context, secret *Secret) error {
 		secretData[k] = v
 	}
 
-	writeReq := schema.KvV2WriteRequest{
-		Data: secretData,
-	}
+	var version int64
+	var err error
+	if c.KVVersion() == 1 {
+		_, err = c.client.Secrets.KvV1Write(ctx, writePath, secretData, vault.WithMountPath(c.config.KVMount))
+	} else {
+		writeReq := schema.KvV2WriteRequest{
+			Data:    secretData,
+			Options: options,
+		}
 
-	_, err := c.client.Secrets.KvV2Write(ctx, writePath, writeReq, vault.WithMountPath(c.config.KVMount))
+		var resp *vault.Response[schema.KvV2WriteResponse]
+		resp, err = c.client.Secrets.KvV2Write(ctx, writePath, writeReq, vault.WithMountPath(c.config.KVMount))
+		if resp != nil {
+			version = resp.Data.Version
+		}
+	}
 	if err != nil {
 		vaultErr := errors.NewWithPath("write_secret", secret.Path, err).
 			WithContext("mount", c.config.KVMount).
 			WithContext("key_count", len(secret.Data)).
 			WithContext("duration_ms", time.Since(start).Milliseconds())
+		if cas, ok := options["cas"]; ok {
+			vaultErr = vaultErr.WithContext("cas", cas)
+		}
 		
 		if responseErr, ok := err.(*vault.ResponseError); ok {
 			vaultErr = vaultErr.
@@ -229,8 +321,11 @@ func (c *Client) WriteSecret(ctx context.Context, secret *Secret) error {
 		return vaultErr
 	}
 
+	secret.Version = version
+
 	logger.InfoCtx(ctx, "Wrote secret successfully", 
 		"path", secret.Path,
+		"version", secret.Version,
 		"key_count", len(secret.Data),
 		"duration_ms", time.Since(start).Milliseconds())
 
@@ -238,7 +333,75 @@ func (c *Client) WriteSecret(ctx context.Context, secret *Secret) error {
 }
 
 func (c *Client) WalkSecrets(ctx context.Context, basePath string, fn func(secretPath string) error) error {
-	return c.walkSecretsRecursive(ctx, basePath, fn)
+	return c.walkSecretsRecursive(ctx, basePath, 0, fn)
+}
+
+// WalkSecretsDepth is WalkSecrets limited to maxDepth levels below
+// basePath: directories at that level are passed to fn with their trailing
+// slash instead of being descended into. A maxDepth of 0 means no limit.
+func (c *Client) WalkSecretsDepth(ctx context.Context, basePath string, maxDepth int, fn func(entryPath string) error) error {
+	return c.walkSecretsRecursive(ctx, basePath, maxDepth, fn)
+}
+
+// IsNotFound reports whether err is a 404 from Vault, as returned for a
+// missing secret or a deleted or destroyed version.
+func IsNotFound(err error) bool {
+	responseErr, ok := unwrapResponseError(err)
+	return ok && responseErr.StatusCode == http.StatusNotFound
+}
+
+// IsCASMismatch reports whether a check-and-set write failed because the
+// secret was changed since the version it was checked against.
+func IsCASMismatch(err error) bool {
+	responseErr, ok := unwrapResponseError(err)
+	if !ok || responseErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, message := range responseErr.Errors {
+		if strings.Contains(message, "check-and-set parameter did not match") {
+			return true
+		}
+	}
+	return false
+}
+
+// unwrapResponseError finds the Vault API error in the chain of err.
+func unwrapResponseError(err error) (*vault.ResponseError, bool) {
+	var responseErr *vault.ResponseError
+	if stderrors.As(err, &responseErr) {
+		return responseErr, true
+	}
+	return nil, false
+}
+
+// annotateResponseError adds the HTTP status and Vault error messages to
+// vaultErr when err was returned by the Vault API.
+func annotateResponseError(vaultErr *errors.VaultSyncError, err error) *errors.VaultSyncError {
+	if responseErr, ok := err.(*vault.ResponseError); ok {
+		vaultErr = vaultErr.
+			WithContext("status_code", responseErr.StatusCode).
+			WithContext("vault_errors", responseErr.Errors)
+	}
+	return vaultErr
+}
+
+// toInt64 converts a numeric field from a decoded Vault response.
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case json.Number:
+		i, _ := n.Int64()
+		return i
+	case float64:
+		return int64(n)
+	case int64:
+		return n
+	case int:
+		return int64(n)
+	case string:
+		i, _ := strconv.ParseInt(n, 10, 64)
+		return i
+	}
+	return 0
 }
 
 func (c *Client) validatePath(path string) error {
@@ -255,7 +418,9 @@ func (c *Client) validatePath(path string) error {
 	return nil
 }
 
-func (c *Client) walkSecretsRecursive(ctx context.Context, currentPath string, fn func(secretPath string) error) error {
+// walkSecretsRecursive walks currentPath. depthLeft counts the levels still
+// to list, with 0 meaning no limit.
+func (c *Client) walkSecretsRecursive(ctx context.Context, currentPath string, depthLeft int, fn func(secretPath string) error) error {
 	logger.DebugCtx(ctx, "Walking secrets recursively", "path", currentPath)
 	
 	secrets, err := c.ListSecrets(ctx, currentPath)
@@ -263,15 +428,20 @@ func (c *Client) walkSecretsRecursive(ctx context.Context, currentPath string, f
 		return errors.WrapWithPath(err, "walk_secrets", currentPath)
 	}
 
-	for _, secretPath := range secrets {
-		fullPath := secretPath
-		if currentPath != "" {
-			fullPath = path.Join(currentPath, secretPath)
+	for _, fullPath := range secrets {
+		// Stop before starting new requests once the operation is cancelled
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		if strings.HasSuffix(secretPath, "/") {
+		// ListSecrets already returns paths prefixed with currentPath
+		if strings.HasSuffix(fullPath, "/") && depthLeft == 1 {
+			if err := fn(fullPath); err != nil {
+				return errors.WrapWithPath(err, "process_directory", fullPath)
+			}
+		} else if strings.HasSuffix(fullPath, "/") {
 			logger.DebugCtx(ctx, "Descending into directory", "path", fullPath)
-			if err := c.walkSecretsRecursive(ctx, strings.TrimSuffix(fullPath, "/"), fn); err != nil {
+			if err := c.walkSecretsRecursive(ctx, strings.TrimSuffix(fullPath, "/"), max(depthLeft-1, 0), fn); err != nil {
 				return errors.WrapWithPath(err, "walk_secrets_recursive", fullPath)
 			}
 		} else {
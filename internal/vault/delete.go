@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault-client-go"
+	"github.com/hashicorp/vault-client-go/schema"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// DeleteVersions soft-deletes versions of a secret. Their data can be
+// restored with UndeleteVersions until they are destroyed.
+func (c *Client) DeleteVersions(ctx context.Context, secretPath string, versions []int64) error {
+	return c.changeVersions(ctx, "delete_versions", secretPath, versions, func(path string, v []int32) error {
+		_, err := c.client.Secrets.KvV2DeleteVersions(ctx, path, schema.KvV2DeleteVersionsRequest{Versions: v},
+			vault.WithMountPath(c.config.KVMount))
+		return err
+	})
+}
+
+// UndeleteVersions restores soft-deleted versions of a secret.
+func (c *Client) UndeleteVersions(ctx context.Context, secretPath string, versions []int64) error {
+	return c.changeVersions(ctx, "undelete_versions", secretPath, versions, func(path string, v []int32) error {
+		_, err := c.client.Secrets.KvV2UndeleteVersions(ctx, path, schema.KvV2UndeleteVersionsRequest{Versions: v},
+			vault.WithMountPath(c.config.KVMount))
+		return err
+	})
+}
+
+// DestroyVersions permanently removes the data of versions of a secret.
+// The versions stay in the metadata, marked as destroyed.
+func (c *Client) DestroyVersions(ctx context.Context, secretPath string, versions []int64) error {
+	return c.changeVersions(ctx, "destroy_versions", secretPath, versions, func(path string, v []int32) error {
+		_, err := c.client.Secrets.KvV2DestroyVersions(ctx, path, schema.KvV2DestroyVersionsRequest{Versions: v},
+			vault.WithMountPath(c.config.KVMount))
+		return err
+	})
+}
+
+func (c *Client) changeVersions(ctx context.Context, op, secretPath string, versions []int64, call func(path string, versions []int32) error) error {
+	start := time.Now()
+	requestPath := strings.TrimPrefix(secretPath, "/")
+
+	logger.DebugCtx(ctx, "Changing secret versions",
+		"op", op,
+		"path", secretPath,
+		"mount", c.config.KVMount,
+		"versions", versions)
+
+	if c.KVVersion() == 1 {
+		return c.unsupportedOnKVv1(op, secretPath, "secret versions")
+	}
+
+	request := make([]int32, len(versions))
+	for i, v := range versions {
+		request[i] = int32(v)
+	}
+
+	if err := call(requestPath, request); err != nil {
+		return annotateResponseError(errors.NewWithPath(op, secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("versions", versions).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	logger.InfoCtx(ctx, "Changed secret versions",
+		"op", op,
+		"path", secretPath,
+		"versions", versions,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// DeleteSecret deletes the latest data of a secret. On KV v2 this
+// soft-deletes the current version; on KV v1, which has no versions, the
+// secret is removed for good.
+func (c *Client) DeleteSecret(ctx context.Context, secretPath string) error {
+	start := time.Now()
+	requestPath := strings.TrimPrefix(secretPath, "/")
+
+	logger.DebugCtx(ctx, "Deleting secret", "path", secretPath, "mount", c.config.KVMount)
+
+	var err error
+	if c.KVVersion() == 1 {
+		_, err = c.client.Secrets.KvV1Delete(ctx, requestPath, vault.WithMountPath(c.config.KVMount))
+	} else {
+		_, err = c.client.Secrets.KvV2Delete(ctx, requestPath, vault.WithMountPath(c.config.KVMount))
+	}
+	if err != nil {
+		return annotateResponseError(errors.NewWithPath("delete_secret", secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	logger.InfoCtx(ctx, "Deleted secret",
+		"path", secretPath,
+		"kv_version", c.KVVersion(),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// DeleteMetadata permanently removes a secret: its metadata and the data of
+// all of its versions.
+func (c *Client) DeleteMetadata(ctx context.Context, secretPath string) error {
+	start := time.Now()
+	requestPath := strings.TrimPrefix(secretPath, "/")
+
+	logger.DebugCtx(ctx, "Deleting secret metadata", "path", secretPath, "mount", c.config.KVMount)
+
+	if c.KVVersion() == 1 {
+		return c.unsupportedOnKVv1("delete_metadata", secretPath, "secret metadata")
+	}
+
+	_, err := c.client.Secrets.KvV2DeleteMetadataAndAllVersions(ctx, requestPath, vault.WithMountPath(c.config.KVMount))
+	if err != nil {
+		return annotateResponseError(errors.NewWithPath("delete_metadata", secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	logger.InfoCtx(ctx, "Deleted secret metadata and all versions",
+		"path", secretPath,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package vault
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"vault-sync/internal/errors"
+)
+
+// ErrKVv1Unsupported is returned for features that only KV v2 provides,
+// such as versions, metadata and check-and-set writes, when the mount is
+// KV version 1.
+var ErrKVv1Unsupported = stderrors.New("not available on KV version 1 mounts")
+
+// IsUnsupported reports whether err was caused by using a KV v2 feature on
+// a KV v1 mount.
+func IsUnsupported(err error) bool {
+	return stderrors.Is(err, ErrKVv1Unsupported)
+}
+
+// KVVersion returns the version of the KV secrets engine the client talks
+// to. KV v1 has no versions or metadata, so secrets read from it always
+// have Version 0.
+func (c *Client) KVVersion() int {
+	return c.kvVersion
+}
+
+// unsupportedOnKVv1 returns the error for using feature on a KV v1 mount.
+func (c *Client) unsupportedOnKVv1(op, secretPath, feature string) error {
+	return errors.NewWithPath(op, secretPath, fmt.Errorf("%s: %w", feature, ErrKVv1Unsupported)).
+		WithContext("mount", c.config.KVMount).
+		WithContext("namespace", c.config.VaultNamespace)
+}
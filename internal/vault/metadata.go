@@ -0,0 +1,252 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault-client-go"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// VersionInfo describes one KV v2 version of a secret.
+type VersionInfo struct {
+	Version      int64
+	CreatedTime  time.Time
+	DeletionTime time.Time
+	Destroyed    bool
+}
+
+// Deleted reports whether the version has been soft-deleted. Vault also sets
+// a future deletion time on versions of secrets with delete_version_after;
+// those stay readable until that time has passed.
+func (v VersionInfo) Deleted() bool {
+	return !v.DeletionTime.IsZero() && !v.DeletionTime.After(time.Now())
+}
+
+// Readable reports whether the data of the version can still be read.
+func (v VersionInfo) Readable() bool {
+	return !v.Deleted() && !v.Destroyed
+}
+
+// SecretMetadata is the KV v2 metadata of a secret.
+type SecretMetadata struct {
+	Path               string
+	CurrentVersion     int64
+	OldestVersion      int64
+	MaxVersions        int64
+	CasRequired        bool
+	DeleteVersionAfter string
+	CustomMetadata     map[string]string
+	CreatedTime        time.Time
+	UpdatedTime        time.Time
+	// Versions is sorted by ascending version number.
+	Versions []VersionInfo
+}
+
+// Version returns the metadata of a specific version, if Vault still keeps it.
+func (m *SecretMetadata) Version(version int64) (VersionInfo, bool) {
+	for _, v := range m.Versions {
+		if v.Version == version {
+			return v, true
+		}
+	}
+	return VersionInfo{}, false
+}
+
+// VersionAt returns the version that was current at t: the newest version
+// created at or before t. It returns false if no kept version is that old.
+func (m *SecretMetadata) VersionAt(t time.Time) (VersionInfo, bool) {
+	for i := len(m.Versions) - 1; i >= 0; i-- {
+		v := m.Versions[i]
+		if !v.CreatedTime.After(t) {
+			return v, true
+		}
+	}
+	return VersionInfo{}, false
+}
+
+func (c *Client) ReadMetadata(ctx context.Context, secretPath string) (*SecretMetadata, error) {
+	start := time.Now()
+	readPath := strings.TrimPrefix(secretPath, "/")
+
+	logger.DebugCtx(ctx, "Reading secret metadata", "path", secretPath, "mount", c.config.KVMount)
+
+	if c.KVVersion() == 1 {
+		return nil, c.unsupportedOnKVv1("read_metadata", secretPath, "secret metadata and history")
+	}
+
+	resp, err := c.client.Secrets.KvV2ReadMetadata(ctx, readPath, vault.WithMountPath(c.config.KVMount))
+	if err != nil {
+		return nil, annotateResponseError(errors.NewWithPath("read_metadata", secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	metadata := &SecretMetadata{
+		Path:               secretPath,
+		CurrentVersion:     resp.Data.CurrentVersion,
+		OldestVersion:      resp.Data.OldestVersion,
+		MaxVersions:        resp.Data.MaxVersions,
+		CasRequired:        resp.Data.CasRequired,
+		DeleteVersionAfter: resp.Data.DeleteVersionAfter,
+		CustomMetadata:     make(map[string]string),
+		CreatedTime:        resp.Data.CreatedTime,
+		UpdatedTime:        resp.Data.UpdatedTime,
+	}
+
+	for k, v := range resp.Data.CustomMetadata {
+		metadata.CustomMetadata[k] = fmt.Sprintf("%v", v)
+	}
+
+	for key, raw := range resp.Data.Versions {
+		version, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			continue
+		}
+		fields, _ := raw.(map[string]interface{})
+		info := VersionInfo{Version: version}
+		info.CreatedTime = parseVaultTime(fields["created_time"])
+		info.DeletionTime = parseVaultTime(fields["deletion_time"])
+		info.Destroyed, _ = fields["destroyed"].(bool)
+		metadata.Versions = append(metadata.Versions, info)
+	}
+
+	sort.Slice(metadata.Versions, func(i, j int) bool {
+		return metadata.Versions[i].Version < metadata.Versions[j].Version
+	})
+
+	logger.DebugCtx(ctx, "Read secret metadata successfully",
+		"path", secretPath,
+		"current_version", metadata.CurrentVersion,
+		"version_count", len(metadata.Versions),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return metadata, nil
+}
+
+// ReadDeletedSecret stands in for ReadSecret when that reports a secret as
+// not found. If the secret exists with a deleted or destroyed latest
+// version, it is returned with empty data and that version, which a
+// check-and-set write has to name. It returns nil if the secret has no
+// metadata either, and always on KV v1, and an error if the latest version
+// is not deleted, as the read then failed for another reason.
+func (c *Client) ReadDeletedSecret(ctx context.Context, secretPath string) (*Secret, error) {
+	if c.KVVersion() == 1 {
+		return nil, nil
+	}
+
+	metadata, err := c.ReadMetadata(ctx, secretPath)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if metadata.CurrentVersion == 0 {
+		return nil, nil
+	}
+	latest, ok := metadata.Version(metadata.CurrentVersion)
+	if !ok || latest.Readable() {
+		return nil, errors.NewWithPath("read_secret", secretPath,
+			fmt.Errorf("latest version %d could not be read although it is not deleted", metadata.CurrentVersion)).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace)
+	}
+
+	logger.DebugCtx(ctx, "Latest version of secret is deleted",
+		"path", secretPath,
+		"current_version", metadata.CurrentVersion)
+
+	return &Secret{
+		Path:    secretPath,
+		Data:    make(map[string]string),
+		Version: metadata.CurrentVersion,
+	}, nil
+}
+
+// MetadataUpdate lists metadata settings to change. Nil fields are left as
+// they are in Vault.
+type MetadataUpdate struct {
+	CustomMetadata     map[string]string
+	MaxVersions        *int64
+	CasRequired        *bool
+	DeleteVersionAfter *string
+}
+
+// WriteCustomMetadata replaces the custom_metadata of a secret. Other
+// metadata settings are left unchanged.
+func (c *Client) WriteCustomMetadata(ctx context.Context, secretPath string, customMetadata map[string]string) error {
+	if customMetadata == nil {
+		customMetadata = make(map[string]string)
+	}
+	return c.WriteMetadata(ctx, secretPath, MetadataUpdate{CustomMetadata: customMetadata})
+}
+
+// WriteMetadata changes the metadata settings set in update. The request is
+// written directly so that explicit zero values such as cas_required=false
+// are sent rather than omitted.
+func (c *Client) WriteMetadata(ctx context.Context, secretPath string, update MetadataUpdate) error {
+	start := time.Now()
+	metadataPath := path.Join(c.config.KVMount, "metadata", strings.TrimPrefix(secretPath, "/"))
+
+	body := make(map[string]interface{})
+	if update.CustomMetadata != nil {
+		values := make(map[string]interface{}, len(update.CustomMetadata))
+		for k, v := range update.CustomMetadata {
+			values[k] = v
+		}
+		body["custom_metadata"] = values
+	}
+	if update.MaxVersions != nil {
+		body["max_versions"] = *update.MaxVersions
+	}
+	if update.CasRequired != nil {
+		body["cas_required"] = *update.CasRequired
+	}
+	if update.DeleteVersionAfter != nil {
+		body["delete_version_after"] = *update.DeleteVersionAfter
+	}
+
+	logger.DebugCtx(ctx, "Writing secret metadata", "path", secretPath, "mount", c.config.KVMount, "fields", len(body))
+
+	if len(body) == 0 {
+		return nil
+	}
+	if c.KVVersion() == 1 {
+		return c.unsupportedOnKVv1("write_metadata", secretPath, "secret metadata")
+	}
+
+	_, err := c.client.Write(ctx, metadataPath, body)
+	if err != nil {
+		return annotateResponseError(errors.NewWithPath("write_metadata", secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	logger.DebugCtx(ctx, "Wrote secret metadata",
+		"path", secretPath,
+		"fields", len(body),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+func parseVaultTime(v interface{}) time.Time {
+	s, _ := v.(string)
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
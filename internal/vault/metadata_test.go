@@ -0,0 +1,75 @@
+package vault
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVersionInfoDeleted(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		info         VersionInfo
+		wantDeleted  bool
+		wantReadable bool
+	}{
+		{
+			name:         "live version",
+			info:         VersionInfo{Version: 1},
+			wantDeleted:  false,
+			wantReadable: true,
+		},
+		{
+			name:         "soft-deleted version",
+			info:         VersionInfo{Version: 1, DeletionTime: now.Add(-time.Hour)},
+			wantDeleted:  true,
+			wantReadable: false,
+		},
+		{
+			// Vault sets a future deletion_time on every version of a secret
+			// with delete_version_after; the version is still readable.
+			name:         "deletion scheduled by delete_version_after",
+			info:         VersionInfo{Version: 1, DeletionTime: now.Add(time.Hour)},
+			wantDeleted:  false,
+			wantReadable: true,
+		},
+		{
+			name:         "destroyed version",
+			info:         VersionInfo{Version: 1, Destroyed: true},
+			wantDeleted:  false,
+			wantReadable: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Deleted(); got != tt.wantDeleted {
+				t.Errorf("Deleted() = %v, want %v", got, tt.wantDeleted)
+			}
+			if got := tt.info.Readable(); got != tt.wantReadable {
+				t.Errorf("Readable() = %v, want %v", got, tt.wantReadable)
+			}
+		})
+	}
+}
+
+func TestVersionInfoFutureDeletionTimeFromVault(t *testing.T) {
+	// deletion_time as Vault returns it in the versions map of the metadata
+	future := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339Nano)
+
+	info := VersionInfo{
+		Version:      2,
+		CreatedTime:  parseVaultTime("2024-01-02T00:00:00.123456Z"),
+		DeletionTime: parseVaultTime(future),
+	}
+	if info.DeletionTime.IsZero() {
+		t.Fatalf("parseVaultTime(%q) returned zero time", future)
+	}
+	if info.Deleted() {
+		t.Errorf("version with deletion_time %s reported as deleted", future)
+	}
+	if !info.Readable() {
+		t.Errorf("version with deletion_time %s reported as unreadable", future)
+	}
+}
@@ -0,0 +1,220 @@
+package vault
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// ErrMountNotFound is returned by DetectMount when the configured mount
+// does not exist in the namespace.
+var ErrMountNotFound = stderrors.New("mount does not exist")
+
+// IsMountNotFound reports whether err was caused by a missing mount.
+func IsMountNotFound(err error) bool {
+	return stderrors.Is(err, ErrMountNotFound)
+}
+
+// MountInfo describes the secrets engine mounted at a path.
+type MountInfo struct {
+	// Path is the mount path without trailing slash, e.g. "kv".
+	Path        string
+	Type        string
+	Description string
+	// KVVersion is 1 or 2 for KV mounts and 0 for other engines.
+	KVVersion int
+	// Source is the endpoint the details were read from.
+	Source string
+}
+
+// IsKV reports whether the mount is a KV secrets engine.
+func (m *MountInfo) IsKV() bool {
+	return m.Type == "kv" || m.Type == "generic"
+}
+
+// Mount returns the mount details found by DetectMount, or nil if they are
+// not known.
+func (c *Client) Mount() *MountInfo {
+	return c.mount
+}
+
+// DetectMount checks that the configured KV mount exists and sets the KV
+// version the client uses from it. It reads sys/internal/ui/mounts/<mount>,
+// which any token with access to the mount may read, and falls back to
+// sys/mounts. When the token may read neither, the configured version (or
+// KV v2) is assumed and a warning is logged.
+func (c *Client) DetectMount(ctx context.Context) error {
+	start := time.Now()
+	mountPath := strings.Trim(c.config.KVMount, "/")
+
+	logger.DebugCtx(ctx, "Detecting mount", "mount", mountPath, "namespace", c.config.VaultNamespace)
+
+	mount, uiErr := c.readMountInformation(ctx, mountPath)
+	if uiErr == nil && mount.Path != mountPath {
+		return c.mountError(fmt.Errorf("%q is not a mount but a path inside the %q mount; use --kv-mount %s and put the rest in --base-path",
+			mountPath, mount.Path, mount.Path))
+	}
+
+	if uiErr != nil {
+		logger.DebugCtx(ctx, "Cannot read mount information, falling back to sys/mounts", "mount", mountPath, "error", uiErr)
+
+		mounts, err := c.listMounts(ctx)
+		switch {
+		case err == nil:
+			mount = findMount(mounts, mountPath)
+			if mount == nil {
+				return c.mountError(fmt.Errorf("%w: %q%s", ErrMountNotFound, mountPath, availableKVMounts(mounts)))
+			}
+		case isStatus(uiErr, http.StatusBadRequest) || isStatus(uiErr, http.StatusNotFound):
+			return c.mountError(fmt.Errorf("%w: %q; check --kv-mount and --vault-namespace", ErrMountNotFound, mountPath))
+		default:
+			logger.WarnCtx(ctx, "Cannot detect mount type, assuming the configured KV version",
+				"mount", mountPath,
+				"kv_version", c.kvVersion,
+				"mount_information_error", uiErr,
+				"sys_mounts_error", err)
+			return nil
+		}
+	}
+
+	if !mount.IsKV() {
+		return c.mountError(fmt.Errorf("mount %q is a %s secrets engine, not KV", mountPath, mount.Type))
+	}
+	if c.config.KVVersion != 0 && c.config.KVVersion != mount.KVVersion {
+		return c.mountError(fmt.Errorf("mount %q is KV version %d, but --kv-version %d was given; leave out --kv-version to use the detected version",
+			mountPath, mount.KVVersion, c.config.KVVersion))
+	}
+
+	c.mount = mount
+	c.kvVersion = mount.KVVersion
+
+	logger.DebugCtx(ctx, "Detected mount",
+		"mount", mount.Path,
+		"type", mount.Type,
+		"kv_version", mount.KVVersion,
+		"source", mount.Source,
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return nil
+}
+
+// ListKVMounts returns the KV mounts in the namespace, as listed by
+// sys/mounts.
+func (c *Client) ListKVMounts(ctx context.Context) ([]*MountInfo, error) {
+	start := time.Now()
+
+	mounts, err := c.listMounts(ctx)
+	if err != nil {
+		return nil, annotateResponseError(errors.New("list_mounts", err).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()).
+			WithContext("hint", "listing mounts needs read access to sys/mounts"), err)
+	}
+
+	var kvMounts []*MountInfo
+	for _, mount := range mounts {
+		if mount.IsKV() {
+			kvMounts = append(kvMounts, mount)
+		}
+	}
+
+	logger.DebugCtx(ctx, "Listed KV mounts",
+		"namespace", c.config.VaultNamespace,
+		"count", len(kvMounts),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return kvMounts, nil
+}
+
+func (c *Client) readMountInformation(ctx context.Context, mountPath string) (*MountInfo, error) {
+	resp, err := c.client.System.InternalUiReadMountInformation(ctx, mountPath)
+	if err != nil {
+		return nil, err
+	}
+	return newMountInfo(resp.Data.Path, resp.Data.Type, resp.Data.Description, resp.Data.Options,
+		"sys/internal/ui/mounts"), nil
+}
+
+// listMounts returns the secrets engines visible in sys/mounts, sorted by
+// path.
+func (c *Client) listMounts(ctx context.Context) ([]*MountInfo, error) {
+	resp, err := c.client.System.MountsListSecretsEngines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mounts []*MountInfo
+	for mountPath, raw := range resp.Data {
+		fields, ok := raw.(map[string]interface{})
+		if !ok || !strings.HasSuffix(mountPath, "/") {
+			continue
+		}
+		mountType, _ := fields["type"].(string)
+		description, _ := fields["description"].(string)
+		options, _ := fields["options"].(map[string]interface{})
+		mounts = append(mounts, newMountInfo(mountPath, mountType, description, options, "sys/mounts"))
+	}
+
+	sort.Slice(mounts, func(i, j int) bool {
+		return mounts[i].Path < mounts[j].Path
+	})
+	return mounts, nil
+}
+
+func newMountInfo(mountPath, mountType, description string, options map[string]interface{}, source string) *MountInfo {
+	mount := &MountInfo{
+		Path:        strings.Trim(mountPath, "/"),
+		Type:        mountType,
+		Description: description,
+		Source:      source,
+	}
+	if mount.IsKV() {
+		// KV v1 mounts have no version option
+		mount.KVVersion = 1
+		if toInt64(options["version"]) == 2 {
+			mount.KVVersion = 2
+		}
+	}
+	return mount
+}
+
+func findMount(mounts []*MountInfo, mountPath string) *MountInfo {
+	for _, mount := range mounts {
+		if mount.Path == mountPath {
+			return mount
+		}
+	}
+	return nil
+}
+
+func availableKVMounts(mounts []*MountInfo) string {
+	var paths []string
+	for _, mount := range mounts {
+		if mount.IsKV() {
+			paths = append(paths, mount.Path)
+		}
+	}
+	if len(paths) == 0 {
+		return "; no KV mounts are visible to this token"
+	}
+	return "; available KV mounts: " + strings.Join(paths, ", ")
+}
+
+func (c *Client) mountError(err error) error {
+	return errors.New("detect_mount", err).
+		WithContext("mount", c.config.KVMount).
+		WithContext("namespace", c.config.VaultNamespace).
+		WithContext("vault_addr", c.config.VaultAddr)
+}
+
+func isStatus(err error, status int) bool {
+	responseErr, ok := unwrapResponseError(err)
+	return ok && responseErr.StatusCode == status
+}
@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault-client-go"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// ListNamespaces returns every namespace below the configured namespace,
+// recursively, as paths relative to it such as "team-a" and "team-a/sub".
+// Parents sort before their children.
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	start := time.Now()
+
+	var namespaces []string
+	var walk func(relative string) error
+	walk = func(relative string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		children, err := c.listChildNamespaces(ctx, relative)
+		if err != nil {
+			return err
+		}
+		for _, child := range children {
+			childPath := path.Join(relative, child)
+			namespaces = append(namespaces, childPath)
+			if err := walk(childPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	sort.Strings(namespaces)
+
+	logger.DebugCtx(ctx, "Listed child namespaces",
+		"namespace", c.config.VaultNamespace,
+		"count", len(namespaces),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return namespaces, nil
+}
+
+// listChildNamespaces lists the direct children of the namespace at
+// relative below the configured namespace. Vault answers with a 404 when
+// there are none.
+func (c *Client) listChildNamespaces(ctx context.Context, relative string) ([]string, error) {
+	namespace := path.Join(c.config.VaultNamespace, relative)
+
+	var options []vault.RequestOption
+	if namespace != "" {
+		options = append(options, vault.WithNamespace(namespace))
+	}
+
+	resp, err := c.client.List(ctx, "sys/namespaces", options...)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, annotateResponseError(errors.New("list_namespaces", err).
+			WithContext("namespace", namespace).
+			WithContext("hint", "listing namespaces needs list access to sys/namespaces"), err)
+	}
+
+	var children []string
+	if resp != nil {
+		keys, _ := resp.Data["keys"].([]interface{})
+		for _, key := range keys {
+			if name, ok := key.(string); ok && strings.Trim(name, "/") != "" {
+				children = append(children, strings.Trim(name, "/"))
+			}
+		}
+	}
+	return children, nil
+}
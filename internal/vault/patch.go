@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault-client-go"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// SecretPatch lists the keys a PATCH sets and removes. Keys not listed keep
+// their value in Vault.
+type SecretPatch struct {
+	Set    map[string]string
+	Remove []string
+}
+
+// Empty reports whether the patch changes nothing.
+func (p SecretPatch) Empty() bool {
+	return len(p.Set) == 0 && len(p.Remove) == 0
+}
+
+// PatchSecret applies patch to version cas of an existing secret with the
+// KV v2 patch endpoint, creating a new version in which only the listed keys
+// changed. Like WriteSecretCAS, it fails if cas is no longer the current
+// version. It returns the version that was created.
+func (c *Client) PatchSecret(ctx context.Context, secretPath string, patch SecretPatch, cas int64) (int64, error) {
+	start := time.Now()
+	dataPath := path.Join(c.config.KVMount, "data", strings.TrimPrefix(secretPath, "/"))
+
+	logger.DebugCtx(ctx, "Patching secret",
+		"path", secretPath,
+		"mount", c.config.KVMount,
+		"set", len(patch.Set),
+		"remove", len(patch.Remove),
+		"cas", cas)
+
+	if c.KVVersion() == 1 {
+		return 0, c.unsupportedOnKVv1("patch_secret", secretPath, "patch writes")
+	}
+
+	// A JSON merge patch: null removes a key
+	data := make(map[string]interface{}, len(patch.Set)+len(patch.Remove))
+	for k, v := range patch.Set {
+		data[k] = v
+	}
+	for _, k := range patch.Remove {
+		data[k] = nil
+	}
+
+	body := map[string]interface{}{
+		"data":    data,
+		"options": map[string]interface{}{"cas": cas},
+	}
+	resp, err := c.client.Write(ctx, dataPath, body,
+		vault.WithRequestCallbacks(func(req *http.Request) {
+			req.Method = http.MethodPatch
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+		}))
+	if err != nil {
+		return 0, annotateResponseError(errors.NewWithPath("patch_secret", secretPath, err).
+			WithContext("mount", c.config.KVMount).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("key_count", len(data)).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	var version int64
+	if resp != nil {
+		version = toInt64(resp.Data["version"])
+	}
+
+	logger.InfoCtx(ctx, "Patched secret successfully",
+		"path", secretPath,
+		"version", version,
+		"key_count", len(data),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return version, nil
+}
+
+// IsPatchRejected reports whether a PATCH failed because the server or the
+// token's policy does not allow it, so that a full write should be used
+// instead. A check-and-set mismatch is not a rejection.
+func IsPatchRejected(err error) bool {
+	if IsUnsupported(err) {
+		return true
+	}
+	responseErr, ok := unwrapResponseError(err)
+	if !ok {
+		return false
+	}
+	switch responseErr.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusForbidden, http.StatusUnsupportedMediaType:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/vault-client-go"
+	"github.com/hashicorp/vault-client-go/schema"
+	"vault-sync/internal/errors"
+	"vault-sync/internal/logger"
+)
+
+// TransitEncrypt encrypts a batch of plaintexts with the named Transit key
+// and returns the ciphertexts in the same order.
+func (c *Client) TransitEncrypt(ctx context.Context, mount, key string, plaintexts []string) ([]string, error) {
+	start := time.Now()
+	if len(plaintexts) == 0 {
+		return nil, nil
+	}
+
+	logger.DebugCtx(ctx, "Encrypting with Transit", "mount", mount, "key", key, "count", len(plaintexts))
+
+	batch := make([]map[string]interface{}, len(plaintexts))
+	for i, plaintext := range plaintexts {
+		batch[i] = map[string]interface{}{
+			"plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext)),
+		}
+	}
+
+	resp, err := c.client.Secrets.TransitEncrypt(ctx, key,
+		schema.TransitEncryptRequest{BatchInput: batch},
+		vault.WithMountPath(mount))
+	if err != nil {
+		return nil, annotateResponseError(errors.New("transit_encrypt", err).
+			WithContext("transit_mount", mount).
+			WithContext("transit_key", key).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	results, err := transitBatchResults(resp, "ciphertext", len(plaintexts))
+	if err != nil {
+		return nil, errors.New("transit_encrypt", err).
+			WithContext("transit_mount", mount).
+			WithContext("transit_key", key)
+	}
+
+	logger.DebugCtx(ctx, "Encrypted with Transit successfully",
+		"mount", mount,
+		"key", key,
+		"count", len(results),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return results, nil
+}
+
+// TransitDecrypt decrypts a batch of Transit ciphertexts and returns the
+// plaintexts in the same order.
+func (c *Client) TransitDecrypt(ctx context.Context, mount, key string, ciphertexts []string) ([]string, error) {
+	start := time.Now()
+	if len(ciphertexts) == 0 {
+		return nil, nil
+	}
+
+	logger.DebugCtx(ctx, "Decrypting with Transit", "mount", mount, "key", key, "count", len(ciphertexts))
+
+	batch := make([]map[string]interface{}, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		batch[i] = map[string]interface{}{"ciphertext": ciphertext}
+	}
+
+	resp, err := c.client.Secrets.TransitDecrypt(ctx, key,
+		schema.TransitDecryptRequest{BatchInput: batch},
+		vault.WithMountPath(mount))
+	if err != nil {
+		return nil, annotateResponseError(errors.New("transit_decrypt", err).
+			WithContext("transit_mount", mount).
+			WithContext("transit_key", key).
+			WithContext("namespace", c.config.VaultNamespace).
+			WithContext("duration_ms", time.Since(start).Milliseconds()), err)
+	}
+
+	encoded, err := transitBatchResults(resp, "plaintext", len(ciphertexts))
+	if err != nil {
+		return nil, errors.New("transit_decrypt", err).
+			WithContext("transit_mount", mount).
+			WithContext("transit_key", key)
+	}
+
+	results := make([]string, len(encoded))
+	for i, value := range encoded {
+		plaintext, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, errors.New("transit_decrypt", fmt.Errorf("decode plaintext: %w", err)).
+				WithContext("transit_key", key)
+		}
+		results[i] = string(plaintext)
+	}
+
+	logger.DebugCtx(ctx, "Decrypted with Transit successfully",
+		"mount", mount,
+		"key", key,
+		"count", len(results),
+		"duration_ms", time.Since(start).Milliseconds())
+
+	return results, nil
+}
+
+func transitBatchResults(resp *vault.Response[map[string]interface{}], field string, expected int) ([]string, error) {
+	if resp == nil {
+		return nil, fmt.Errorf("empty response from Vault")
+	}
+
+	items, _ := resp.Data["batch_results"].([]interface{})
+	if len(items) != expected {
+		return nil, fmt.Errorf("expected %d batch results, got %d", expected, len(items))
+	}
+
+	results := make([]string, len(items))
+	for i, item := range items {
+		result, _ := item.(map[string]interface{})
+		if msg, ok := result["error"].(string); ok && msg != "" {
+			return nil, fmt.Errorf("batch item %d: %s", i, msg)
+		}
+		value, ok := result[field].(string)
+		if !ok {
+			return nil, fmt.Errorf("batch item %d has no %s", i, field)
+		}
+		results[i] = value
+	}
+	return results, nil
+}
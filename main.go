@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 
 	"vault-sync/cmd"
 	"vault-sync/internal/errors"
@@ -15,24 +14,30 @@ import (
 
 func main() {
 	// Set up context with cancellation for graceful shutdown
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Handle interrupt signals
-	c := make(chan os.Signal, 1)
+	// The first interrupt cancels the context so commands stop starting new
+	// work and let in-flight writes finish; a second one exits immediately.
+	c := make(chan os.Signal, 2)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-c
 		logger.Info("Received interrupt signal, shutting down gracefully...")
+		fmt.Fprintln(os.Stderr, "\nInterrupted: finishing in-flight operations (press Ctrl-C again to force quit)")
 		cancel()
-		
-		// Give operations 5 seconds to finish gracefully
-		time.Sleep(5 * time.Second)
-		logger.Error("Forced shutdown after timeout")
-		os.Exit(1)
+
+		<-c
+		logger.Error("Forced shutdown on second interrupt")
+		os.Exit(130)
 	}()
 
-	if err := cmd.Execute(); err != nil {
+	if err := cmd.Execute(ctx); err != nil {
+		if ctx.Err() != nil {
+			logger.Info("Operation interrupted", "error", err.Error())
+			fmt.Fprintln(os.Stderr, "Operation interrupted")
+			os.Exit(130)
+		}
 		handleError(err)
 		os.Exit(1)
 	}